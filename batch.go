@@ -0,0 +1,118 @@
+package protoid
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// BatchError holds the errors for the messages that DecodeBatch couldn't decode, keyed by their index in the input.
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	first := -1
+	for i := range e.Errors {
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+	return fmt.Sprintf("failed to decode %d messages, first was message %d : %v", len(e.Errors), first, e.Errors[first])
+}
+
+// Unwrap returns the individual errors in the order of the messages they belong to, so that errors.Is and errors.As can see them.
+func (e *BatchError) Unwrap() []error {
+	idx := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	errs := make([]error, len(idx))
+	for j, i := range idx {
+		errs[j] = e.Errors[i]
+	}
+	return errs
+}
+
+// BatchOptions controls how DecodeBatch shares out its work.
+type BatchOptions struct {
+	// Workers is how many messages DecodeBatch decodes at once.  It defaults to GOMAXPROCS.
+	Workers int
+}
+
+// DecodeBatch decodes each of inputs with DecodeNodes, using a bounded number of goroutines.  The results are in the same order as
+// inputs.  A message that fails to decode doesn't stop the others: its result is nil and its error is included in the returned
+// *BatchError.  If ctx is cancelled, messages that haven't been decoded yet, including any that are part way through, fail with the
+// context's error.
+func DecodeBatch(ctx context.Context, inputs [][]byte, opts Options, batch BatchOptions) ([][]*Node, error) {
+	return NewDecoder(opts).DecodeBatch(ctx, inputs, batch)
+}
+
+// DecodeBatch is like the package level DecodeBatch, using the options of d.
+func (d *Decoder) DecodeBatch(ctx context.Context, inputs [][]byte, batch BatchOptions) ([][]*Node, error) {
+	workers := batch.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	results := make([][]*Node, len(inputs))
+	errs := make([]error, len(inputs))
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range next {
+				results[idx], errs[idx] = d.decodeNodesContext(ctx, inputs[idx])
+			}
+		}()
+	}
+
+feed:
+	for i := range inputs {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			for ; i < len(inputs); i++ {
+				errs[i] = ctx.Err()
+			}
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	be := &BatchError{Errors: make(map[int]error)}
+	for i, err := range errs {
+		if err != nil {
+			be.Errors[i] = err
+		}
+	}
+	if len(be.Errors) > 0 {
+		return results, be
+	}
+	return results, nil
+}
+
+// decodeNodesContext is DecodeNodes, giving up if ctx is cancelled part way through.
+func (d *Decoder) decodeNodesContext(ctx context.Context, input []byte) ([]*Node, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	st := d.get()
+	defer d.put(st)
+	var res Result
+	if err := st.na.decode(ctx, input, &res, &st.w); err != nil {
+		return nil, err
+	}
+	return res.Fields, nil
+}
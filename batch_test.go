@@ -0,0 +1,119 @@
+package protoid
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDecodeBatch(t *testing.T) {
+	assert := assert.New(t)
+
+	var inputs [][]byte
+	for i := 0; i < 100; i++ {
+		var ser []byte
+		ser = protowire.AppendTag(ser, 1, protowire.VarintType)
+		ser = protowire.AppendVarint(ser, uint64(i))
+		if i%10 == 3 {
+			// truncate some of them
+			ser = ser[:1]
+		}
+		inputs = append(inputs, ser)
+	}
+
+	results, err := DecodeBatch(context.Background(), inputs, Options{}, BatchOptions{Workers: 4})
+
+	var be *BatchError
+	if assert.True(errors.As(err, &be)) {
+		assert.Len(be.Errors, 10)
+		assert.Equal(ErrUnexpectedEndOfInput, be.Errors[3])
+	}
+	assert.True(errors.Is(err, ErrUnexpectedEndOfInput))
+
+	assert.Len(results, 100)
+	for i, nodes := range results {
+		if i%10 == 3 {
+			assert.Nil(nodes)
+			continue
+		}
+		if assert.Len(nodes, 1) {
+			assert.Equal(uint64(i), nodes[0].Value)
+		}
+	}
+}
+
+func TestDecodeBatchCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inputs := [][]byte{{0x08, 0x01}, {0x08, 0x02}}
+	results, err := DecodeBatch(ctx, inputs, Options{}, BatchOptions{})
+
+	assert.True(errors.Is(err, context.Canceled))
+	assert.Equal([][]*Node{nil, nil}, results)
+}
+
+// cancellingVisitor cancels its context after the first field it sees.
+type cancellingVisitor struct {
+	recordingVisitor
+	cancel func()
+}
+
+func (cv *cancellingVisitor) Varint(path Path, value uint64) error {
+	cv.cancel()
+	return cv.recordingVisitor.Varint(path, value)
+}
+
+func TestWalkCancelledMidMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	var ser []byte
+	for i := 0; i < 10000; i++ {
+		ser = protowire.AppendTag(ser, 1, protowire.VarintType)
+		ser = protowire.AppendVarint(ser, uint64(i))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cv := &cancellingVisitor{cancel: cancel}
+	var w walker
+	err := w.walk(ctx, ser, cv)
+
+	assert.Equal(context.Canceled, err)
+	assert.True(len(cv.events) <= cancelCheckInterval)
+}
+
+func TestWalkCancelledWhileScanning(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// one huge embedded message
+	var emb []byte
+	for i := 0; i < 10000; i++ {
+		emb = protowire.AppendTag(emb, 1, protowire.VarintType)
+		emb = protowire.AppendVarint(emb, uint64(i))
+	}
+	ser := protowire.AppendTag(nil, 1, protowire.BytesType)
+	ser = protowire.AppendBytes(ser, emb)
+
+	cv := &cancellingVisitor{cancel: cancel}
+	var w walker
+	assert.Equal(context.Canceled, w.walk(ctx, ser, cv))
+	assert.Empty(cv.events)
+
+	// one huge string, which doesn't parse as a message
+	ser = protowire.AppendTag(nil, 1, protowire.BytesType)
+	ser = protowire.AppendString(ser, strings.Repeat("é", 1<<20))
+
+	cv = &cancellingVisitor{cancel: cancel}
+	w = walker{}
+	assert.Equal(context.Canceled, w.walk(ctx, ser, cv))
+	assert.Empty(cv.events)
+}
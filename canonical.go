@@ -0,0 +1,162 @@
+package protoid
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"slices"
+)
+
+// Equal returns whether two messages are equal according to protocol buffers' rules, as far as they can be applied without a schema:
+// field order, packing and the encoding of varints don't matter, and a varint or fixed width field written more than once only counts
+// with its last value, as a scalar field set twice does.
+//
+// Without a schema some of protocol buffers' rules can't be applied, so messages are only compared as far as protoid can tell:
+//   - protoid can't tell a repeated field from a scalar field that was written more than once.  The last value wins, so repeated fields
+//     written unpacked are only compared by their last values; CanonicalOptions.Repeated compares all of them instead.  A field that
+//     also occurs packed must be repeated, so all of its values are compared either way.  Embedded messages written more than once
+//     aren't merged.
+//   - A length-delimited field that only ever occurs length-delimited is kept as it is, so a packed field split over several chunks
+//     isn't recognised as equal to the same values in a single chunk.
+//   - A length-delimited value that looks like a message is treated as one, so two strings that parse as the same fields in a different
+//     order are considered equal.
+//   - A scalar value and a bytes value with the same encoding in the same field, which can only happen if the messages have different
+//     schemas, are considered equal.
+func Equal(a, b []byte) (bool, error) {
+	return CanonicalOptions{}.Equal(a, b)
+}
+
+// CanonicalHash returns the SHA-256 hash of a deterministic re-encoding of msg, so that messages that are Equal have the same hash.  It
+// is intended for deduplicating messages.
+func CanonicalHash(msg []byte) ([sha256.Size]byte, error) {
+	return CanonicalOptions{}.CanonicalHash(msg)
+}
+
+// CanonicalOptions controls how Equal and CanonicalHash settle what protoid can't tell without a schema.
+type CanonicalOptions struct {
+	// Repeated treats a varint or fixed width field that occurs more than once as a repeated field, comparing all of its values in
+	// order, instead of as a scalar field written more than once whose last value wins.
+	Repeated bool
+}
+
+// Equal is like the package level Equal, using the options of o.
+func (o CanonicalOptions) Equal(a, b []byte) (bool, error) {
+	ca, err := o.canonicalize(a)
+	if err != nil {
+		return false, err
+	}
+	cb, err := o.canonicalize(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ca, cb), nil
+}
+
+// CanonicalHash is like the package level CanonicalHash, using the options of o.
+func (o CanonicalOptions) CanonicalHash(msg []byte) ([sha256.Size]byte, error) {
+	c, err := o.canonicalize(msg)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(c), nil
+}
+
+// canonicalize re-encodes a message deterministically, so that messages that Equal considers equal have the same encoding.  Fields are
+// written in field number order and varints with as few bytes as possible, and embedded messages are canonicalized too.  Every varint
+// and fixed width field is written packed, so that it doesn't matter whether the producer packed repeated fields; as scalar fields
+// can't be packed, the result is only for comparing and hashing, and isn't a valid encoding of msg.
+func (o CanonicalOptions) canonicalize(msg []byte) ([]byte, error) {
+	return o.appendCanonical(nil, msg)
+}
+
+// appendCanonical appends the canonical encoding of msg to buf.
+func (o CanonicalOptions) appendCanonical(buf, msg []byte) ([]byte, error) {
+	spans, err := scanFields(msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	// a stable sort keeps the occurrences of each field in order
+	slices.SortStableFunc(spans, func(a, b fieldSpan) int { return a.num - b.num })
+
+	for start := 0; start < len(spans); {
+		end := start + 1
+		for end < len(spans) && spans[end].num == spans[start].num {
+			end++
+		}
+		buf, err = o.appendCanonicalField(buf, spans[start:end])
+		if err != nil {
+			return nil, err
+		}
+		start = end
+	}
+	return buf, nil
+}
+
+// appendCanonicalField appends the canonical encoding of every occurrence of a field.
+func (o CanonicalOptions) appendCanonicalField(buf []byte, occurrences []fieldSpan) ([]byte, error) {
+	num := occurrences[0].num
+	scalar := WireBytes
+	delimited := false
+	for _, s := range occurrences {
+		switch {
+		case s.wiretype == WireBytes:
+			delimited = true
+		case scalar == WireBytes:
+			scalar = s.wiretype
+		}
+	}
+	if !o.Repeated && scalar != WireBytes && !delimited {
+		occurrences = occurrences[len(occurrences)-1:]
+	}
+
+	if scalar == WireBytes {
+		for _, s := range occurrences {
+			buf = appendTag(buf, num, WireBytes)
+			spans, err := scanFields(s.data, nil)
+			var cands [3]Candidate
+			if bytesCandidates(s.data, spans, err == nil, cands[:0])[0].Kind != KindMessage {
+				buf = appendLenDelimValue(buf, s.data)
+				continue
+			}
+			emb, err := o.appendCanonical(nil, s.data)
+			if err != nil {
+				// can't happen, scanFields has already checked the structure.
+				return nil, err
+			}
+			buf = appendLenDelimValue(buf, emb)
+		}
+		return buf, nil
+	}
+
+	// write every value as a single packed field
+	var packed []byte
+	for _, s := range occurrences {
+		switch s.wiretype {
+		case WireVarint:
+			packed = appendVarint(packed, s.value)
+		case WireFixed64:
+			packed = appendLeUint64(packed, s.value)
+		case WireFixed32:
+			packed = appendLeUint32(packed, uint32(s.value))
+		case WireBytes:
+			packed = appendPacked(packed, s.data, scalar)
+		}
+	}
+	buf = appendTag(buf, num, WireBytes)
+	return appendLenDelimValue(buf, packed), nil
+}
+
+// appendPacked appends the elements of a packed field of the given wire type, re-encoding varints canonically.  If data can't be read
+// as packed values it is appended as it is.
+func appendPacked(buf, data []byte, wiretype WireType) []byte {
+	if wiretype != WireVarint {
+		return append(buf, data...)
+	}
+	values, ok := packedVarints(data)
+	if !ok {
+		return append(buf, data...)
+	}
+	for _, v := range values {
+		buf = appendVarint(buf, v)
+	}
+	return buf
+}
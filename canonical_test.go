@@ -0,0 +1,104 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	packed, err := proto.Marshal(&RepeatedInt32{MyInt32S: []int32{1, 150, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nested, reordered []byte
+	nested = protowire.AppendTag(nested, 1, protowire.BytesType)
+	nested = protowire.AppendBytes(nested, append(varints(1, 5), varints(2, 6)...))
+	nested = append(nested, varints(2, 7)...)
+	reordered = append(reordered, varints(2, 7)...)
+	reordered = protowire.AppendTag(reordered, 1, protowire.BytesType)
+	reordered = protowire.AppendBytes(reordered, append(varints(2, 6), varints(1, 5)...))
+
+	for _, tt := range []struct {
+		name  string
+		a, b  []byte
+		equal bool
+	}{
+		{"identical", packed, packed, true},
+		{"packed and unpacked", packed, append(varints(1, 1), 0x0a, 0x03, 0x96, 0x01, 0x03), true},
+		{"single packed value", []byte{0x0a, 0x01, 0x05}, varints(1, 5), true},
+		{"padded varint", []byte{0x08, 0x96, 0x01}, []byte{0x08, 0x96, 0x81, 0x00}, true},
+		{"reordered nested fields", nested, reordered, true},
+		{"scalar set twice", []byte{0x08, 0x01, 0x08, 0x02}, []byte{0x08, 0x02}, true},
+		{"last value differs", varints(1, 1, 2), varints(1, 2, 1), false},
+		{"earlier values ignored", varints(1, 1, 2), varints(1, 3, 2), true},
+		{"packed values kept", append(packed, varints(1, 3)...), append(packed, varints(1, 4)...), false},
+		{"different values", varints(1, 1), varints(1, 2), false},
+		{"different fields", varints(1, 1), varints(2, 1), false},
+	} {
+		equal, err := Equal(tt.a, tt.b)
+		assert.NoError(err, tt.name)
+		assert.Equal(tt.equal, equal, tt.name)
+
+		ha, err := CanonicalHash(tt.a)
+		assert.NoError(err, tt.name)
+		hb, err := CanonicalHash(tt.b)
+		assert.NoError(err, tt.name)
+		assert.Equal(tt.equal, ha == hb, tt.name)
+	}
+
+	_, err = Equal([]byte{0x0a, 0x05}, packed)
+	assert.Equal(ErrUnexpectedEndOfInput, err)
+}
+
+func TestEqualRepeated(t *testing.T) {
+	assert := assert.New(t)
+
+	packed, err := proto.Marshal(&RepeatedInt32{MyInt32S: []int32{1, 150, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := CanonicalOptions{Repeated: true}
+
+	for _, tt := range []struct {
+		name  string
+		a, b  []byte
+		equal bool
+	}{
+		{"packed and unpacked", packed, varints(1, 1, 150, 3), true},
+		{"repeated order matters", varints(1, 1, 2), varints(1, 2, 1), false},
+		{"every value counts", varints(1, 1, 2), varints(1, 2), false},
+		{"padded varint", []byte{0x08, 0x96, 0x01}, []byte{0x08, 0x96, 0x81, 0x00}, true},
+	} {
+		equal, err := opts.Equal(tt.a, tt.b)
+		assert.NoError(err, tt.name)
+		assert.Equal(tt.equal, equal, tt.name)
+
+		ha, err := opts.CanonicalHash(tt.a)
+		assert.NoError(err, tt.name)
+		hb, err := opts.CanonicalHash(tt.b)
+		assert.NoError(err, tt.name)
+		assert.Equal(tt.equal, ha == hb, tt.name)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	assert := assert.New(t)
+
+	ss, err := proto.Marshal(&TwoStrings{String_1: "string1", String_2: "string2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := CanonicalOptions{}.canonicalize(ss)
+	assert.NoError(err)
+	assert.Equal(ss, c)
+
+	c, err = CanonicalOptions{}.canonicalize(append(varints(2, 300), varints(1, 1)...))
+	assert.NoError(err)
+	assert.Equal([]byte{0x0a, 0x01, 0x01, 0x12, 0x02, 0xac, 0x02}, c)
+}
@@ -0,0 +1,214 @@
+package protoid
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ViolationType is the kind of problem found by Check.
+type ViolationType int
+
+const (
+	// WireTypeMismatch is a field whose wire type can't be that of its declared type.
+	WireTypeMismatch ViolationType = iota
+	// UnknownField is a field number that the message type doesn't declare.
+	UnknownField
+	// OutOfRange is a value that doesn't fit its declared type, such as a varint too large for an int32 or an unknown value of a
+	// closed enum.
+	OutOfRange
+	// InvalidUTF8 is a string field that isn't valid UTF-8, where the protocol buffers runtime would reject it: in proto3, or where the
+	// editions utf8_validation feature asks for it, but not in proto2.
+	InvalidUTF8
+	// MalformedValue is an embedded message or packed field that can't be parsed.
+	MalformedValue
+)
+
+var violationTypeNames = map[ViolationType]string{
+	WireTypeMismatch: "wire-type-mismatch",
+	UnknownField:     "unknown-field",
+	OutOfRange:       "out-of-range",
+	InvalidUTF8:      "invalid-utf8",
+	MalformedValue:   "malformed-value",
+}
+
+func (vt ViolationType) String() string {
+	if name, ok := violationTypeNames[vt]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Violation is a single way in which a message doesn't conform to its schema.  Path is the path of field numbers to the field, and Name
+// the same path made of field names, with the number of any unknown field in place of its name.
+type Violation struct {
+	Type   ViolationType
+	Path   string
+	Name   string
+	Detail string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s %s (%s): %s", v.Type, v.Path, v.Name, v.Detail)
+}
+
+// Check walks msg as a message of the type described by md, reporting every field that contradicts it.  Unlike unmarshalling, it
+// carries on past problems so that all of them are found.  An error is only returned if the structure of msg itself is malformed;
+// problems with embedded messages are reported as violations.  Fields in an extension range are assumed to be valid extensions.
+func Check(msg []byte, md protoreflect.MessageDescriptor) ([]Violation, error) {
+	var c checker
+	if err := c.message(msg, md, nil, ""); err != nil {
+		return nil, err
+	}
+	return c.violations, nil
+}
+
+type checker struct {
+	violations []Violation
+}
+
+func (c *checker) add(vt ViolationType, path Path, name, detail string) {
+	c.violations = append(c.violations, Violation{Type: vt, Path: path.String(), Name: name, Detail: detail})
+}
+
+// message checks the fields of data against md.
+func (c *checker) message(data []byte, md protoreflect.MessageDescriptor, path Path, name string) error {
+	it := NewIterator(data)
+	for it.Next() {
+		f := it.Field()
+		p := append(path[:len(path):len(path)], f.Number)
+		num := protoreflect.FieldNumber(f.Number)
+
+		fd := md.Fields().ByNumber(num)
+		if fd == nil {
+			switch {
+			case md.ExtensionRanges().Has(num):
+			case md.ReservedRanges().Has(num):
+				c.add(UnknownField, p, joinName(name, strconv.Itoa(f.Number)), fmt.Sprintf("field %d is reserved in %s", f.Number, md.FullName()))
+			default:
+				c.add(UnknownField, p, joinName(name, strconv.Itoa(f.Number)), fmt.Sprintf("%s has no field %d", md.FullName(), f.Number))
+			}
+			continue
+		}
+		c.field(f, fd, p, joinName(name, string(fd.Name())))
+	}
+	return it.Err()
+}
+
+// field checks a single occurrence of a declared field.
+func (c *checker) field(f Field, fd protoreflect.FieldDescriptor, path Path, name string) {
+	expected := kindWireType(fd.Kind())
+	if f.WireType != expected {
+		if f.WireType == WireBytes && fd.IsList() && expected != WireBytes {
+			c.packed(f.Value, fd, expected, path, name)
+			return
+		}
+		c.add(WireTypeMismatch, path, name, fmt.Sprintf("declared %v (%v), found %v", fd.Kind(), expected, f.WireType))
+		return
+	}
+
+	switch f.WireType {
+	case WireVarint:
+		r := reader{buf: f.Value}
+		c.varint(r.decodeVarint(), fd, path, name)
+	case WireBytes:
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			if !utf8.Valid(f.Value) && validatesUTF8(fd) {
+				c.add(InvalidUTF8, path, name, fmt.Sprintf("%d bytes of invalid UTF-8", len(f.Value)))
+			}
+		case protoreflect.MessageKind:
+			if err := c.message(f.Value, fd.Message(), path, name); err != nil {
+				c.add(MalformedValue, path, name, fmt.Sprintf("embedded %s: %v", fd.Message().FullName(), err))
+			}
+		}
+	}
+}
+
+// packed checks the elements of a packed repeated field.
+func (c *checker) packed(data []byte, fd protoreflect.FieldDescriptor, wiretype WireType, path Path, name string) {
+	r := reader{buf: data}
+	for !r.done() {
+		switch wiretype {
+		case WireVarint:
+			v := r.decodeVarint()
+			if r.err == nil {
+				c.varint(v, fd, path, name)
+			}
+		case WireFixed64:
+			r.readLeUint64()
+		case WireFixed32:
+			r.readLeUint32()
+		}
+	}
+	if r.err != nil {
+		c.add(MalformedValue, path, name, fmt.Sprintf("packed %v: %v", fd.Kind(), r.err))
+	}
+}
+
+// varint checks that a varint value is in range for the declared type.
+func (c *checker) varint(v uint64, fd protoreflect.FieldDescriptor, path Path, name string) {
+	if varintFits(v, fd) {
+		return
+	}
+	if fd.Kind() == protoreflect.EnumKind && int64(v) >= math.MinInt32 && int64(v) <= math.MaxInt32 {
+		c.add(OutOfRange, path, name, fmt.Sprintf("%d is not a value of closed enum %s", int32(v), fd.Enum().FullName()))
+		return
+	}
+	c.add(OutOfRange, path, name, fmt.Sprintf("%d is out of range for %v", v, fd.Kind()))
+}
+
+// varintFits returns whether a varint value is in range for the declared type.
+func varintFits(v uint64, fd protoreflect.FieldDescriptor) bool {
+	switch fd.Kind() {
+	case protoreflect.Int32Kind:
+		return int64(v) >= math.MinInt32 && int64(v) <= math.MaxInt32
+	case protoreflect.Uint32Kind, protoreflect.Sint32Kind:
+		return v <= math.MaxUint32
+	case protoreflect.EnumKind:
+		if int64(v) < math.MinInt32 || int64(v) > math.MaxInt32 {
+			return false
+		}
+		return !fd.Enum().IsClosed() || fd.Enum().Values().ByNumber(protoreflect.EnumNumber(v)) != nil
+	default:
+		return true
+	}
+}
+
+// validatesUTF8 returns whether the protocol buffers runtime rejects invalid UTF-8 in the string field fd: it does for proto3, not for
+// proto2, and as the utf8_validation feature says for editions.
+func validatesUTF8(fd protoreflect.FieldDescriptor) bool {
+	// descriptors built by the protobuf module resolve the editions
+	// feature, and report it the same way to the runtime itself
+	if e, ok := fd.(interface{ EnforceUTF8() bool }); ok {
+		return e.EnforceUTF8()
+	}
+	return fd.ParentFile() == nil || fd.ParentFile().Syntax() != protoreflect.Proto2
+}
+
+// kindWireType returns the wire type used for a declared type.
+func kindWireType(k protoreflect.Kind) WireType {
+	switch k {
+	case protoreflect.BoolKind, protoreflect.EnumKind, protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind:
+		return WireVarint
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind, protoreflect.FloatKind:
+		return WireFixed32
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind, protoreflect.DoubleKind:
+		return WireFixed64
+	case protoreflect.GroupKind:
+		return WireStartGroup
+	default:
+		return WireBytes
+	}
+}
+
+func joinName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
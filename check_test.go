@@ -0,0 +1,90 @@
+package protoid
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/runtime/protoiface"
+	"google.golang.org/protobuf/runtime/protoimpl"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func descriptorOf(m protoiface.MessageV1) protoreflect.MessageDescriptor {
+	return protoimpl.X.MessageDescriptorOf(m)
+}
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	embedded := func(inner []byte) []byte {
+		ser := protowire.AppendTag(nil, 1, protowire.BytesType)
+		return protowire.AppendBytes(ser, inner)
+	}
+	fixed32 := protowire.AppendFixed32(protowire.AppendTag(nil, 1, protowire.Fixed32Type), 7)
+
+	for _, tt := range []struct {
+		name       string
+		msg        []byte
+		md         protoreflect.MessageDescriptor
+		violations []Violation
+	}{
+		{"valid", embedded(append(protowire.AppendTag(nil, 1, protowire.BytesType), 1, 'a')), descriptorOf(&SingleEmbedded{}), nil},
+		{"wire type", embedded(fixed32), descriptorOf(&SingleEmbedded{}), []Violation{
+			{Type: WireTypeMismatch, Path: "1.1", Name: "my_single_string.the_string", Detail: "declared string (length-delimited), found fixed32"},
+		}},
+		{"unknown field", slices.Concat(varints(1, 5), varints(5, 1)), descriptorOf(&SingleInt32{}), []Violation{
+			{Type: UnknownField, Path: "5", Name: "5", Detail: "protoid.SingleInt32 has no field 5"},
+		}},
+		{"int32 range", varints(1, 1<<40), descriptorOf(&SingleInt32{}), []Violation{
+			{Type: OutOfRange, Path: "1", Name: "the_int32", Detail: "1099511627776 is out of range for int32"},
+		}},
+		{"negative int32", varints(1, 1<<64-5), descriptorOf(&SingleInt32{}), nil},
+		// every runtime reads any non-zero varint as true
+		{"bool", varints(1, 1<<40), descriptorOf(&SingleBool{}), nil},
+		{"utf8", append(protowire.AppendTag(nil, 1, protowire.BytesType), 2, 0xff, 0xfe), descriptorOf(&SingleString{}), []Violation{
+			{Type: InvalidUTF8, Path: "1", Name: "the_string", Detail: "2 bytes of invalid UTF-8"},
+		}},
+		{"proto2 utf8", append(protowire.AppendTag(nil, 1, protowire.BytesType), 2, 0xff, 0xfe), proto2Message(t), nil},
+		{"malformed embedded", embedded([]byte{0x0a, 0x05}), descriptorOf(&SingleEmbedded{}), []Violation{
+			{Type: MalformedValue, Path: "1", Name: "my_single_string", Detail: "embedded protoid.SingleString: unexpected end of input"},
+		}},
+		{"packed", embedded([]byte{0x01, 0x96, 0x01}), descriptorOf(&RepeatedInt32{}), nil},
+		{"packed range", embedded(protowire.AppendVarint(nil, 1<<40)), descriptorOf(&RepeatedInt32{}), []Violation{
+			{Type: OutOfRange, Path: "1", Name: "my_int32s", Detail: "1099511627776 is out of range for int32"},
+		}},
+	} {
+		violations, err := Check(tt.msg, tt.md)
+		assert.NoError(err, tt.name)
+		assert.Equal(tt.violations, violations, tt.name)
+	}
+
+	_, err := Check([]byte{0x0a}, descriptorOf(&SingleString{}))
+	assert.Error(err)
+}
+
+// proto2Message returns a proto2 message type with an optional string field 1, whose UTF-8 the protobuf runtime doesn't validate.
+func proto2Message(t *testing.T) protoreflect.MessageDescriptor {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("p2.proto"),
+		Package: proto.String("p2"),
+		Syntax:  proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Msg"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("s"),
+				Number: proto.Int32(1),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Messages().Get(0)
+}
@@ -0,0 +1,248 @@
+package protoid
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFieldNumber is the largest field number allowed by protocol buffers.
+const maxFieldNumber = 1<<29 - 1
+
+// stringSampleSize is how much of a value that parses as a message is checked when scoring it as a string.  Checking all of it would
+// mean scanning the contents of deeply nested messages once for every level of nesting.
+const stringSampleSize = 256
+
+// stringCheckInterval is how many bytes of a value stringScoreContext checks between looking at its context.
+const stringCheckInterval = 64 << 10
+
+// InterpretBytes returns the possible interpretations of a length-delimited value (an embedded message, string or bytes), most plausible
+// first.  Packed repeated fields are not considered; see InterpretPacked.
+func InterpretBytes(data []byte) []Candidate {
+	spans, err := scanFields(data, nil)
+	cands := bytesCandidates(data, spans, err == nil, nil)
+	out := cands[:0]
+	for _, c := range cands {
+		switch c.Kind {
+		case KindMessage:
+			emb, err := Decode(data)
+			if err != nil {
+				// scanFields has already checked the structure, so this
+				// shouldn't happen, but if Decode disagrees there is no
+				// message to offer.
+				continue
+			}
+			c.Value = emb
+		case KindString:
+			c.Value = string(data)
+		case KindBytes:
+			c.Value = copyBytes(data)
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// fieldSpan is a single field found by scanFields.  Offsets are relative to the start of the data that was scanned.
+type fieldSpan struct {
+	num      int
+	wiretype WireType
+	value    uint64 // the value of a varint or fixed width field
+	data     []byte // the contents of a length-delimited field
+	off      int    // where the tag starts
+	valueOff int    // where the value starts, after any length prefix
+	end      int    // where the field ends
+}
+
+// scanFields parses the top level of the message in data once, appending its fields to spans.  The values of length-delimited fields
+// are not looked at.  If data isn't structurally valid, an error is returned along with whatever fields were found before the problem.
+func scanFields(data []byte, spans []fieldSpan) ([]fieldSpan, error) {
+	return scanFieldsContext(context.Background(), data, spans)
+}
+
+// scanFieldsContext is scanFields, giving up with the context's error if ctx is cancelled part way through.
+func scanFieldsContext(ctx context.Context, data []byte, spans []fieldSpan) ([]fieldSpan, error) {
+	r := reader{buf: data}
+	for n := 1; !r.done(); n++ {
+		if n%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return spans, err
+			}
+		}
+		off := r.off
+		val := r.decodeVarint()
+		if r.err != nil {
+			break
+		}
+		s := fieldSpan{num: int(val >> 3), wiretype: WireType(val & 0x07), off: off, valueOff: r.off}
+		switch s.wiretype {
+		case WireVarint: // varint value (int32, int64, uint32, uint64, sint32, sint64, bool, enum)
+			s.value = r.decodeVarint()
+		case WireFixed64: // 64 bit value (fixed64, sfixed64, double)
+			s.value = r.readLeUint64()
+		case WireBytes: // length-delimited value (string, bytes, embedded messages, packed repeated fields)
+			s.data = r.readLenDelimValue()
+			s.valueOff = r.off - len(s.data)
+		case WireStartGroup, WireEndGroup: // groups are deprecated
+			return spans, ErrNotImplemented
+		case WireFixed32: // 32-bit value (fixed32, sfixed32, float)
+			s.value = uint64(r.readLeUint32())
+		default:
+			return spans, fmt.Errorf("unsupported wire type : %d", s.wiretype)
+		}
+		if r.err != nil {
+			break
+		}
+		s.end = r.off
+		spans = append(spans, s)
+	}
+	return spans, r.err
+}
+
+// bytesCandidates scores the possible interpretations of a length-delimited value without filling in their values, most plausible first.
+// spans must be the result of scanning data with scanFields, and valid whether that succeeded.  The candidates are appended to buf.
+func bytesCandidates(data []byte, spans []fieldSpan, valid bool, buf []Candidate) []Candidate {
+	cands, _ := bytesCandidatesContext(context.Background(), data, spans, valid, buf)
+	return cands
+}
+
+// bytesCandidatesContext is bytesCandidates, giving up with the context's error if ctx is cancelled part way through.
+func bytesCandidatesContext(ctx context.Context, data []byte, spans []fieldSpan, valid bool, buf []Candidate) ([]Candidate, error) {
+	cands := buf[:0]
+
+	sample := data
+	if valid {
+		if score, reason := messageScore(spans); score > 0 {
+			cands = append(cands, Candidate{Kind: KindMessage, Score: score, Reason: reason})
+			sample = truncateUTF8(data, stringSampleSize)
+		}
+	}
+	score, reason, err := stringScoreContext(ctx, sample)
+	if err != nil {
+		return cands, err
+	}
+	if score > 0 {
+		cands = append(cands, Candidate{Kind: KindString, Score: score, Reason: reason})
+	}
+	cands = append(cands, Candidate{Kind: KindBytes, Score: 0.1, Reason: "opaque-bytes"})
+
+	sortCandidates(cands)
+	return cands, nil
+}
+
+// messageScore rates how likely it is that the fields found by scanFields form an embedded message, or returns 0 if they can't.
+func messageScore(spans []fieldSpan) (float64, string) {
+	if len(spans) == 0 {
+		// An empty string is never written for a proto3 scalar field, but
+		// an empty message is written for a message field that is set.
+		return 0.5, "empty-message"
+	}
+
+	// wire types seen for each of the first 64 field numbers, to spot
+	// inconsistencies without allocating.
+	var seen [8]uint64
+	var delimited, fixed, large, inconsistent bool
+	for _, s := range spans {
+		num := s.num
+		if num == 0 || num > maxFieldNumber || (num >= 19000 && num <= 19999) {
+			// field 0 and the reserved range can never appear
+			return 0, "invalid-field-number"
+		}
+		if num > 2047 {
+			large = true
+		}
+		if num < 64 {
+			bit := uint64(1) << uint(num)
+			for wt := range seen {
+				if WireType(wt) != s.wiretype && seen[wt]&bit != 0 {
+					inconsistent = true
+				}
+			}
+			seen[s.wiretype] |= bit
+		}
+
+		switch s.wiretype {
+		case WireFixed64, WireFixed32:
+			fixed = true
+		case WireBytes:
+			delimited = true
+		}
+	}
+
+	// Any run of small bytes parses as a sequence of varints, but a length
+	// prefix or fixed width value that exactly fills the remaining data is
+	// much less likely to happen by accident.
+	var score float64
+	var reason string
+	switch {
+	case delimited:
+		score, reason = 0.9, "valid-nested-message"
+	case fixed:
+		score, reason = 0.6, "fixed-only-message"
+	default:
+		score, reason = 0.4, "varint-only-message"
+	}
+	if large {
+		score -= 0.3
+	}
+	if inconsistent {
+		score -= 0.3
+	}
+	if score < 0.05 {
+		score = 0.05
+	}
+	return score, reason
+}
+
+// stringScore rates how likely it is that data is a string, or returns 0 if it isn't valid UTF-8.
+func stringScore(data []byte) (float64, string) {
+	score, reason, _ := stringScoreContext(context.Background(), data)
+	return score, reason
+}
+
+// stringScoreContext is stringScore, giving up with the context's error if ctx is cancelled part way through.
+func stringScoreContext(ctx context.Context, data []byte) (float64, string, error) {
+	if len(data) == 0 {
+		return 0.3, "empty-string", nil
+	}
+
+	var total, printable int
+	check := stringCheckInterval
+	for i := 0; i < len(data); {
+		if i >= check {
+			if err := ctx.Err(); err != nil {
+				return 0, "", err
+			}
+			check += stringCheckInterval
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return 0, "invalid-utf8", nil
+		}
+		i += size
+		total++
+		if unicode.IsPrint(r) || r == '\t' || r == '\n' || r == '\r' {
+			printable++
+		}
+	}
+
+	// Text rarely contains control characters, so even a few should count
+	// heavily against it.
+	if printable == total {
+		return 0.85, "printable-utf8", nil
+	}
+	ratio := float64(printable) / float64(total)
+	return 0.85 * ratio * ratio * ratio * ratio, "control-characters", nil
+}
+
+// truncateUTF8 returns at most the first n bytes of data, without splitting a multi-byte character.
+func truncateUTF8(data []byte, n int) []byte {
+	if len(data) <= n {
+		return data
+	}
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return data[:n]
+}
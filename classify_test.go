@@ -0,0 +1,69 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// classifyCorpus holds length-delimited values that have been misclassified in the past, along with what they really are.
+var classifyCorpus = []struct {
+	name string
+	data []byte
+	kind Kind
+}{
+	// short strings that happen to parse as a single varint field
+	{"hi", []byte("hi"), KindString},
+	{"HiHi", []byte("HiHi"), KindString},
+	{"digits", []byte("08"), KindString},
+	{"xx", []byte("xx"), KindString},
+	// a tag followed by exactly four bytes looks like a fixed32 field
+	{"percent", []byte("%abc"), KindString},
+	{"hi!", []byte("hi!"), KindString},
+	{"sentence", []byte("The quick brown fox"), KindString},
+	{"unicode", []byte("naïve café"), KindString},
+
+	// this is exactly the encoding of a message holding a one character
+	// string at field 1, so it is correct to treat it as one
+	{"nested string", []byte("\n\x01a"), KindMessage},
+	// a message whose encoding happens to be entirely printable
+	{"printable message", []byte("\n\n0123456789"), KindMessage},
+	{"varint field", []byte{0x08, 0x96, 0x01}, KindMessage},
+	{"fixed32 field", []byte{0x25, 0x00, 0x00, 0xc0, 0x3f}, KindMessage},
+	{"empty", []byte{}, KindMessage},
+
+	// control character soup is valid UTF-8 but isn't text
+	{"control characters", []byte{0x01, 0x02, 0x03, 0x04, 0x05}, KindBytes},
+	{"field zero", []byte{0x00, 0x01, 0x02}, KindBytes},
+	{"invalid utf8", []byte{0xff, 0xfe, 0xfd}, KindBytes},
+	{"reserved field number", []byte{0xc0, 0xb8, 0x09, 0x01}, KindBytes},
+}
+
+func TestInterpretBytesCorpus(t *testing.T) {
+	for _, tt := range classifyCorpus {
+		t.Run(tt.name, func(t *testing.T) {
+			cands := InterpretBytes(tt.data)
+			assert.Equal(t, tt.kind, cands[0].Kind, "%q", tt.data)
+		})
+	}
+}
+
+func TestShortStringNotMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &TwoStrings{String_1: "hi", String_2: "08"}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]interface{}{1: "hi", 2: "08"}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
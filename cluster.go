@@ -0,0 +1,158 @@
+package protoid
+
+import "sort"
+
+// ClusterOptions controls a Clusterer.
+type ClusterOptions struct {
+	// Threshold is how similar a message's fields have to be to those of a cluster for it to join the cluster, as the Jaccard index of
+	// their ShapeFeatures.  It defaults to 0.5.
+	Threshold float64
+}
+
+// Cluster is a group of messages with similar fields, which are likely to be of the same type.
+type Cluster struct {
+	ID int
+	// Members holds the indices of the messages in the cluster, in the order they were added to the Clusterer.
+	Members []int
+	// Features holds the fields found in at least half of the members, as given by ShapeFeatures.
+	Features []string
+	// Exemplars holds the indices of up to three members that best represent the cluster, most representative first.
+	Exemplars []int
+	// Shape is the shape of all the members.
+	Shape *Shape
+}
+
+// maxExemplars is how many exemplars each Cluster has.
+const maxExemplars = 3
+
+// Clusterer groups messages by their fields, for telling apart the types of messages in a stream that mixes several of them.  Each
+// message joins the most similar existing cluster, or starts a new one if none is similar enough.  It is a single pass, so the clusters
+// can depend on the order of the messages.
+type Clusterer struct {
+	opts     ClusterOptions
+	messages int
+	clusters []*clusterState
+}
+
+type clusterState struct {
+	counts   map[string]int // how many members have each feature
+	coreSize int            // how many features are in at least half of the members
+	members  []int
+	shapes   map[Fingerprint]*shapeGroup
+	shape    Shape
+}
+
+// shapeGroup is the members of a cluster with identical fields.
+type shapeGroup struct {
+	features []string
+	first    int
+	count    int
+}
+
+// NewClusterer returns an empty Clusterer.
+func NewClusterer(opts ClusterOptions) *Clusterer {
+	if opts.Threshold == 0 {
+		opts.Threshold = 0.5
+	}
+	return &Clusterer{opts: opts}
+}
+
+// Add decodes msg and adds it to the best cluster, returning the ID of the cluster.
+func (c *Clusterer) Add(msg []byte) (int, error) {
+	nodes, err := DecodeNodes(msg, Options{})
+	if err != nil {
+		return 0, err
+	}
+	return c.AddNodes(nodes), nil
+}
+
+// AddNodes adds an already decoded message to the best cluster, returning the ID of the cluster.
+func (c *Clusterer) AddNodes(nodes []*Node) int {
+	features := ShapeFeatures(nodes)
+
+	best, bestScore := -1, 0.0
+	for i, cs := range c.clusters {
+		if score := cs.similarity(features); score >= c.opts.Threshold && score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		best = len(c.clusters)
+		c.clusters = append(c.clusters, &clusterState{counts: make(map[string]int), shapes: make(map[Fingerprint]*shapeGroup)})
+	}
+
+	cs := c.clusters[best]
+	cs.members = append(cs.members, c.messages)
+	for _, f := range features {
+		cs.counts[f]++
+	}
+	cs.coreSize = 0
+	for _, n := range cs.counts {
+		if n*2 >= len(cs.members) {
+			cs.coreSize++
+		}
+	}
+	fp := featuresFingerprint(features)
+	g := cs.shapes[fp]
+	if g == nil {
+		g = &shapeGroup{features: features, first: c.messages}
+		cs.shapes[fp] = g
+	}
+	g.count++
+	cs.shape.AddNodes(nodes)
+
+	c.messages++
+	return best
+}
+
+// similarity returns the Jaccard index of features and the features of at least half of the members of the cluster.
+func (cs *clusterState) similarity(features []string) float64 {
+	inter := 0
+	for _, f := range features {
+		if cs.counts[f]*2 >= len(cs.members) {
+			inter++
+		}
+	}
+	union := len(features) + cs.coreSize - inter
+	if union == 0 {
+		return 1
+	}
+	return float64(inter) / float64(union)
+}
+
+// Clusters returns the clusters found so far, in the order they were started.
+func (c *Clusterer) Clusters() []Cluster {
+	clusters := make([]Cluster, len(c.clusters))
+	for i, cs := range c.clusters {
+		cl := Cluster{ID: i, Members: cs.members, Shape: &cs.shape}
+		for f, n := range cs.counts {
+			if n*2 >= len(cs.members) {
+				cl.Features = append(cl.Features, f)
+			}
+		}
+		sort.Strings(cl.Features)
+
+		// the most representative members are those closest to the core
+		// features, preferring the most common shapes.
+		groups := make([]*shapeGroup, 0, len(cs.shapes))
+		for _, g := range cs.shapes {
+			groups = append(groups, g)
+		}
+		score := func(g *shapeGroup) float64 { return cs.similarity(g.features) }
+		sort.Slice(groups, func(i, j int) bool {
+			si, sj := score(groups[i]), score(groups[j])
+			if si != sj {
+				return si > sj
+			}
+			if groups[i].count != groups[j].count {
+				return groups[i].count > groups[j].count
+			}
+			return groups[i].first < groups[j].first
+		})
+		for _, g := range groups[:min(len(groups), maxExemplars)] {
+			cl.Exemplars = append(cl.Exemplars, g.first)
+		}
+		clusters[i] = cl
+	}
+	return clusters
+}
@@ -0,0 +1,77 @@
+package protoid
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestShapeFingerprint(t *testing.T) {
+	assert := assert.New(t)
+
+	decode := func(msg []byte) []*Node {
+		nodes, err := DecodeNodes(msg, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nodes
+	}
+
+	var nested []byte
+	nested = protowire.AppendTag(nested, 2, protowire.BytesType)
+	nested = protowire.AppendBytes(nested, varints(1, 5))
+	nested = append(nested, varints(1, 1, 2)...)
+	assert.Equal([]string{"1:varint", "2.1:varint", "2:length-delimited"}, ShapeFeatures(decode(nested)))
+
+	assert.Equal(ShapeFingerprint(decode(varints(1, 1))), ShapeFingerprint(decode(varints(1, 2, 3))))
+	assert.NotEqual(ShapeFingerprint(decode(varints(1, 1))), ShapeFingerprint(decode(varints(2, 1))))
+	assert.Len(ShapeFingerprint(nil).String(), 16)
+}
+
+func TestClusterer(t *testing.T) {
+	assert := assert.New(t)
+
+	str := func(num protowire.Number, s string) []byte {
+		ser := protowire.AppendTag(nil, num, protowire.BytesType)
+		return protowire.AppendString(ser, s)
+	}
+	fixed := func(num protowire.Number, v uint64) []byte {
+		ser := protowire.AppendTag(nil, num, protowire.Fixed64Type)
+		return protowire.AppendFixed64(ser, v)
+	}
+	msgs := [][]byte{
+		slices.Concat(str(1, "alice"), varints(2, 30)),                         // 0: person
+		slices.Concat(fixed(5, 1), fixed(6, 2), varints(7, 1)),                 // 1: point
+		slices.Concat(str(1, "bob"), varints(2, 41), str(3, "bob@x")),          // 2: person with email
+		slices.Concat(fixed(5, 3), fixed(6, 4), varints(7, 2)),                 // 3: point
+		slices.Concat(str(1, "carol"), varints(2, 25)),                         // 4: person
+		slices.Concat(varints(10, 1, 2, 3), str(11, "an order"), fixed(12, 9)), // 5: order
+	}
+
+	c := NewClusterer(ClusterOptions{})
+	var ids []int
+	for _, msg := range msgs {
+		id, err := c.Add(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	assert.Equal([]int{0, 1, 0, 1, 0, 2}, ids)
+
+	clusters := c.Clusters()
+	if !assert.Len(clusters, 3) {
+		return
+	}
+	assert.Equal([]int{0, 2, 4}, clusters[0].Members)
+	assert.Equal([]string{"1:length-delimited", "2:varint"}, clusters[0].Features)
+	assert.Equal([]int{0, 2}, clusters[0].Exemplars)
+	assert.Equal(3, clusters[0].Shape.Samples)
+	assert.Equal([]int{1, 3}, clusters[1].Members)
+	assert.Equal([]int{5}, clusters[2].Members)
+
+	_, err := c.Add([]byte{0x0a})
+	assert.Error(err)
+}
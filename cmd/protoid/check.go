@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/uw-labs/protoid"
+)
+
+func check(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	descriptors := addDescriptorFlags(fs, "the message type")
+	typeName := fs.String("type", "", "full name of the message type, e.g. pkg.Msg")
+	delimited := fs.Bool("delimited", false, "read files as streams of length-delimited messages")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid check (--descriptor-set file | --proto file.proto) --type pkg.Msg [flags] path...")
+		fmt.Fprintln(fs.Output(), "Exits with status 1 if any message doesn't conform to the type.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	md, err := descriptors.messageType(*typeName)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		err := readMessages(path, *delimited, func(name string, msg []byte) error {
+			violations, err := protoid.Check(msg, md)
+			if err != nil {
+				fmt.Printf("%s: %v\n", name, err)
+				failed = true
+				return nil
+			}
+			for _, v := range violations {
+				fmt.Printf("%s: %v\n", name, v)
+			}
+			failed = failed || len(violations) > 0
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if failed {
+		return exitStatus(1)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uw-labs/protoid"
+)
+
+func cluster(args []string) error {
+	fs := flag.NewFlagSet("cluster", flag.ExitOnError)
+	delimited := fs.Bool("delimited", false, "read files as streams of length-delimited messages")
+	threshold := fs.Float64("threshold", 0.5, "how similar the fields of a message must be to a cluster's to join it, from 0 to 1")
+	out := fs.String("out", "", "write the messages of each cluster to a numbered directory under this one")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid cluster [flags] path...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	c := protoid.NewClusterer(protoid.ClusterOptions{Threshold: *threshold})
+	var names []string
+	var msgs [][]byte
+	for _, path := range fs.Args() {
+		err := readMessages(path, *delimited, func(name string, msg []byte) error {
+			if _, err := c.Add(msg); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			names = append(names, name)
+			if *out != "" {
+				msgs = append(msgs, append([]byte(nil), msg...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	clusters := c.Clusters()
+	sort.SliceStable(clusters, func(i, j int) bool { return len(clusters[i].Members) > len(clusters[j].Members) })
+	for i, cl := range clusters {
+		fmt.Printf("cluster %d: %d messages (%.1f%%)\n", i+1, len(cl.Members), 100*float64(len(cl.Members))/float64(len(names)))
+		fmt.Printf("  fields: %s\n", strings.Join(cl.Features, " "))
+		for _, m := range cl.Exemplars {
+			fmt.Printf("  exemplar: %s\n", names[m])
+		}
+
+		if *out == "" {
+			continue
+		}
+		dir := filepath.Join(*out, fmt.Sprintf("cluster-%d", i+1))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		for _, m := range cl.Members {
+			if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.bin", m)), msgs[m], 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/reporter"
+	"github.com/uw-labs/protoid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// stringList is a flag that can be given more than once.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// descriptorFlags are the flags of a command that loads message types, either from a descriptor set or from .proto source files.
+type descriptorFlags struct {
+	descriptorSet *string
+	protoFiles    stringList
+	importPaths   stringList
+}
+
+// addDescriptorFlags adds the --descriptor-set, --proto and -I flags to fs.  what describes the message types to load.
+func addDescriptorFlags(fs *flag.FlagSet, what string) *descriptorFlags {
+	df := &descriptorFlags{
+		descriptorSet: fs.String("descriptor-set", "", "FileDescriptorSet holding "+what+", e.g. from protoc --descriptor_set_out --include_imports"),
+	}
+	fs.Var(&df.protoFiles, "proto", ".proto source file holding "+what+", instead of a descriptor set (repeatable)")
+	fs.Var(&df.importPaths, "I", "directory to look for .proto imports in (repeatable)")
+	fs.Var(&df.importPaths, "proto-path", "same as -I")
+	return df
+}
+
+// given returns whether any message types were asked for.
+func (df *descriptorFlags) given() bool {
+	return *df.descriptorSet != "" || len(df.protoFiles) > 0
+}
+
+// load loads the message types named by the flags.
+func (df *descriptorFlags) load() (*protoregistry.Files, error) {
+	switch {
+	case *df.descriptorSet != "" && len(df.protoFiles) > 0:
+		return nil, errors.New("only one of --descriptor-set and --proto can be given")
+	case len(df.protoFiles) > 0:
+		return loadProtoFiles(df.importPaths, df.protoFiles...)
+	case *df.descriptorSet != "":
+		data, err := os.ReadFile(*df.descriptorSet)
+		if err != nil {
+			return nil, err
+		}
+		return protoid.LoadDescriptorSet(data)
+	default:
+		return nil, errors.New("--descriptor-set or --proto is required")
+	}
+}
+
+// messageType loads the message types named by the flags and looks up the named one.
+func (df *descriptorFlags) messageType(name string) (protoreflect.MessageDescriptor, error) {
+	if name == "" {
+		return nil, errors.New("--type is required")
+	}
+	files, err := df.load()
+	if err != nil {
+		return nil, err
+	}
+	return protoid.FindMessage(files, name)
+}
+
+// schemaFlags are the flags of a command that can decode messages of a known type, naming their fields.
+type schemaFlags struct {
+	descriptors *descriptorFlags
+	typeName    *string
+}
+
+// addSchemaFlags adds the --type flag to fs, along with the flags to load it from.
+func addSchemaFlags(fs *flag.FlagSet) schemaFlags {
+	return schemaFlags{
+		descriptors: addDescriptorFlags(fs, "the message type, to decode the fields it declares by name"),
+		typeName:    fs.String("type", "", "full name of the message type, e.g. pkg.Msg"),
+	}
+}
+
+// messageType returns the message type named by the flags, or nil if none was asked for.
+func (sf schemaFlags) messageType() (protoreflect.MessageDescriptor, error) {
+	if !sf.descriptors.given() && *sf.typeName == "" {
+		return nil, nil
+	}
+	return sf.descriptors.messageType(*sf.typeName)
+}
+
+// loadProtoFiles compiles .proto source files, along with everything they import, without needing protoc.  Imports are looked up in
+// importPaths, or the current directory if there are none, and the well-known types such as google/protobuf/timestamp.proto are built
+// in.  Each name is either relative to one of the import paths, as in an import statement, or the path of a file inside one of them.
+// Every syntax or type error found is returned, each with the file, line and column it was found at.
+func loadProtoFiles(importPaths []string, names ...string) (*protoregistry.Files, error) {
+	names = slices.Clone(names)
+	for i, name := range names {
+		names[i] = protoFileName(importPaths, name)
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	c := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{ImportPaths: importPaths}),
+		// report every error rather than just the first
+		Reporter: reporter.NewReporter(func(err reporter.ErrorWithPos) error {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+			return nil
+		}, nil),
+	}
+	compiled, err := c.Compile(context.Background(), names...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	files := new(protoregistry.Files)
+	for _, fd := range compiled {
+		if err := registerFile(files, fd); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// protoFileName returns the name that protocompile knows the .proto file at path by: its path relative to the import path that holds
+// it.  path is returned as it is if it is already relative to an import path, or isn't inside any of them.
+func protoFileName(importPaths []string, path string) string {
+	for _, dir := range importPaths {
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			return path
+		}
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	for _, dir := range importPaths {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(absDir, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return path
+}
+
+// registerFile adds fd to files after the files it imports, unless it is already there.
+func registerFile(files *protoregistry.Files, fd protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(fd.Path()); err == nil {
+		return nil
+	}
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(fd)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uw-labs/protoid"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestLoadProtoFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	write := func(name, src string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("pkg/common.proto", `syntax = "proto3";
+package pkg;
+message Common { string id = 1; }
+`)
+	write("pkg/msg.proto", `syntax = "proto3";
+package pkg;
+import "pkg/common.proto";
+import "google/protobuf/timestamp.proto";
+message Msg {
+  Common common = 1;
+  google.protobuf.Timestamp at = 2;
+}
+`)
+	write("bad.proto", `syntax = "proto3";
+message Bad {
+  string id = ;
+  Missing m = 2;
+}
+`)
+
+	// by import name, and by path
+	for _, name := range []string{"pkg/msg.proto", filepath.Join(dir, "pkg", "msg.proto")} {
+		files, err := loadProtoFiles([]string{dir}, name)
+		if !assert.NoError(err) {
+			continue
+		}
+		md, err := protoid.FindMessage(files, "pkg.Msg")
+		if assert.NoError(err) {
+			assert.Equal(protoreflect.FullName("pkg.Common"), md.Fields().ByName("common").Message().FullName())
+		}
+		_, err = protoid.FindMessage(files, "google.protobuf.Timestamp")
+		assert.NoError(err)
+	}
+
+	_, err := loadProtoFiles([]string{dir}, "bad.proto")
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "bad.proto:3:")
+	}
+	_, err = loadProtoFiles([]string{dir}, "missing.proto")
+	assert.Error(err)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/uw-labs/protoid"
+)
+
+func diff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	var opts protoid.DiffOptions
+	fs.BoolVar(&opts.IgnoreOrder, "ignore-order", false, "ignore the order of fields and of repeated elements")
+	fs.BoolVar(&opts.IgnorePacking, "ignore-packing", false, "treat packed repeated fields as equal to the same values unpacked")
+	fs.BoolVar(&opts.IgnoreEncoding, "ignore-encoding", false, "ignore equal values that are encoded differently, e.g. padded varints")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid diff [flags] a b")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	a, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	changes, err := protoid.Diff(a, b, opts)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+	if len(changes) > 0 {
+		// like diff(1)
+		return exitStatus(1)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/uw-labs/protoid"
+)
+
+func drift(args []string) error {
+	fs := flag.NewFlagSet("drift", flag.ExitOnError)
+	save := fs.String("save", "", "also save the baseline shape as JSON to this file, for reuse")
+	delimited := fs.Bool("delimited", false, "read files as streams of length-delimited messages")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid drift [flags] baseline current...")
+		fmt.Fprintln(fs.Output(), "baseline is a file or directory of historical messages, or a baseline saved as JSON with -save.")
+		fmt.Fprintln(fs.Output(), "Exits with status 1 if any breaking drift is found.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	baseline, err := loadShape(fs.Arg(0), *delimited)
+	if err != nil {
+		return err
+	}
+	if *save != "" {
+		data, err := json.MarshalIndent(baseline, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*save, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	var current protoid.Shape
+	for _, path := range fs.Args()[1:] {
+		if err := addMessages(&current, path, *delimited); err != nil {
+			return err
+		}
+	}
+
+	drifts := protoid.DetectDrift(baseline, &current)
+	for _, d := range drifts {
+		fmt.Println(d)
+	}
+	if protoid.HasBreakingDrift(drifts) {
+		return exitStatus(1)
+	}
+	return nil
+}
+
+// loadShape loads a shape saved as JSON, or builds one from the messages at path.
+func loadShape(path string, delimited bool) (*protoid.Shape, error) {
+	var s protoid.Shape
+	if strings.HasSuffix(path, ".json") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &s, nil
+	}
+	if err := addMessages(&s, path, delimited); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func addMessages(s *protoid.Shape, path string, delimited bool) error {
+	return readMessages(path, delimited, func(name string, msg []byte) error {
+		if err := s.Add(msg); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/uw-labs/protoid"
+	"golang.org/x/term"
+)
+
+func explore(args []string) error {
+	fs := flag.NewFlagSet("explore", flag.ExitOnError)
+	minConfidence := fs.Float64("min-confidence", 0, "show values interpreted with less confidence than this as raw bytes")
+	schema := addSchemaFlags(fs)
+	fs.Parse(args)
+
+	md, err := schema.messageType()
+	if err != nil {
+		return err
+	}
+	input, err := readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	opts := protoid.Options{MinConfidence: *minConfidence, Type: md}
+	ex := newExplorer(protoid.DecodeTree(input, opts), opts)
+
+	// The message may have been read from standard input, so talk to the
+	// terminal directly.
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer tty.Close()
+	fd := int(tty.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	// switch to the alternate screen and hide the cursor
+	fmt.Fprint(tty, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(tty, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 64)
+	for {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			return err
+		}
+		ex.resize(width, height)
+		fmt.Fprint(tty, "\x1b[H"+strings.Join(ex.render(), "\x1b[K\r\n")+"\x1b[K")
+
+		n, err := tty.Read(buf)
+		if err != nil {
+			return err
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			if ex.handleKey(k) {
+				return nil
+			}
+		}
+	}
+}
+
+// parseKeys splits what was read from a terminal in raw mode into key names, e.g. "up", "tab" or "q".
+func parseKeys(b []byte) []string {
+	escapes := map[string]string{
+		"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+		"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+		"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+		"[5~": "pgup", "[6~": "pgdown", "[Z": "backtab",
+	}
+
+	var keys []string
+	for len(b) > 0 {
+		if b[0] == 0x1b && len(b) > 1 {
+			seq := ""
+			for name := range escapes {
+				if strings.HasPrefix(string(b[1:]), name) && len(name) > len(seq) {
+					seq = name
+				}
+			}
+			if seq != "" {
+				keys = append(keys, escapes[seq])
+				b = b[1+len(seq):]
+				continue
+			}
+		}
+
+		switch b[0] {
+		case 0x03:
+			keys = append(keys, "ctrl-c")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case ' ':
+			keys = append(keys, "space")
+		case 0x1b:
+			keys = append(keys, "esc")
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// entry is a field shown in the explorer's tree.
+type entry struct {
+	node  *protoid.Node
+	path  protoid.Path
+	depth int
+	// alts are the interpretations that can be cycled through, and alt the one being shown.
+	alts     []protoid.Candidate
+	alt      int
+	expanded bool
+	// children are the entries for the fields of the value as a message, built when first needed.
+	children []*entry
+	err      error
+}
+
+func newEntry(input []byte, n *protoid.Node, path protoid.Path) *entry {
+	e := &entry{node: n, path: path, depth: len(path) - 1}
+	e.alts = append(e.alts, n.Candidates...)
+	if n.WireType == protoid.WireBytes {
+		data := input[n.ValueOffset:n.End]
+		// the alternatives to an embedded message have no values
+		for i := range e.alts {
+			switch e.alts[i].Kind {
+			case protoid.KindString:
+				e.alts[i].Value = string(data)
+			case protoid.KindBytes:
+				e.alts[i].Value = data
+			}
+		}
+		e.alts = append(e.alts, protoid.InterpretPacked(data)...)
+	}
+	for i, c := range e.alts {
+		if c.Kind == n.Kind {
+			e.alt = i
+			break
+		}
+	}
+	return e
+}
+
+func (e *entry) interpretation() protoid.Candidate {
+	if len(e.alts) == 0 {
+		return protoid.Candidate{Kind: e.node.Kind, Value: e.node.Value, Score: e.node.Confidence, Reason: e.node.Reason}
+	}
+	return e.alts[e.alt]
+}
+
+func (e *entry) isMessage() bool {
+	return e.interpretation().Kind == protoid.KindMessage
+}
+
+// loadChildren builds the entries for the fields of e, decoding its value as a message if protoid didn't guess that it was one.
+func (e *entry) loadChildren(input []byte, opts protoid.Options) {
+	if e.children != nil || e.err != nil {
+		return
+	}
+	nodes := e.node.Children
+	if e.node.Kind != protoid.KindMessage {
+		var err error
+		// the type of the outermost message says nothing about this one
+		opts.Type = nil
+		nodes, err = protoid.DecodeValue(input, e.node, opts)
+		if err != nil {
+			e.err = err
+			return
+		}
+	}
+	e.children = make([]*entry, 0, len(nodes))
+	for _, n := range nodes {
+		path := append(e.path[:len(e.path):len(e.path)], n.Field)
+		e.children = append(e.children, newEntry(input, n, path))
+	}
+}
+
+// explorer holds the state of the explore command's user interface.  It doesn't touch the terminal itself, so that it can be tested.
+type explorer struct {
+	tree *protoid.Tree
+	opts protoid.Options
+
+	roots   []*entry
+	visible []*entry // the entries currently shown in the tree, in order
+	cursor  int      // the selected entry in visible
+	top     int      // the first entry of visible on screen
+	hexTop  int      // the first row of the hex pane on screen
+
+	width, height int
+}
+
+func newExplorer(tree *protoid.Tree, opts protoid.Options) *explorer {
+	ex := &explorer{tree: tree, opts: opts, width: 80, height: 24}
+	for _, n := range tree.Fields {
+		ex.roots = append(ex.roots, newEntry(tree.Input, n, protoid.Path{n.Field}))
+	}
+	ex.refresh()
+	return ex
+}
+
+// refresh rebuilds the list of visible entries after entries have been expanded or collapsed.
+func (ex *explorer) refresh() {
+	var selected *entry
+	if ex.cursor < len(ex.visible) {
+		selected = ex.visible[ex.cursor]
+	}
+	ex.visible = ex.visible[:0]
+	var add func(entries []*entry)
+	add = func(entries []*entry) {
+		for _, e := range entries {
+			ex.visible = append(ex.visible, e)
+			if e.expanded && e.isMessage() {
+				add(e.children)
+			}
+		}
+	}
+	add(ex.roots)
+
+	ex.cursor = 0
+	for i, e := range ex.visible {
+		if e == selected {
+			ex.cursor = i
+		}
+	}
+}
+
+func (ex *explorer) resize(width, height int) {
+	ex.width, ex.height = width, height
+}
+
+func (ex *explorer) selected() *entry {
+	if len(ex.visible) == 0 {
+		return nil
+	}
+	return ex.visible[ex.cursor]
+}
+
+// handleKey updates the explorer for a key press, returning true if it should quit.
+func (ex *explorer) handleKey(key string) bool {
+	e := ex.selected()
+	switch key {
+	case "q", "ctrl-c", "esc":
+		return true
+	case "up", "k":
+		ex.cursor--
+	case "down", "j":
+		ex.cursor++
+	case "pgup":
+		ex.cursor -= ex.bodyHeight()
+	case "pgdown":
+		ex.cursor += ex.bodyHeight()
+	case "home":
+		ex.cursor = 0
+	case "end":
+		ex.cursor = len(ex.visible) - 1
+	case "right", "l":
+		if e == nil || !e.isMessage() {
+			break
+		}
+		if e.expanded {
+			ex.cursor++
+			break
+		}
+		e.loadChildren(ex.tree.Input, ex.opts)
+		e.expanded = true
+		ex.refresh()
+	case "left", "h":
+		if e == nil {
+			break
+		}
+		if e.expanded && e.isMessage() {
+			e.expanded = false
+			ex.refresh()
+			break
+		}
+		// move to the parent
+		for i := ex.cursor - 1; i >= 0; i-- {
+			if ex.visible[i].depth < e.depth {
+				ex.cursor = i
+				break
+			}
+		}
+	case "enter", "space":
+		if e == nil || !e.isMessage() {
+			break
+		}
+		e.loadChildren(ex.tree.Input, ex.opts)
+		e.expanded = !e.expanded
+		ex.refresh()
+	case "tab", "i":
+		if e != nil && len(e.alts) > 0 {
+			e.alt = (e.alt + 1) % len(e.alts)
+			ex.refresh()
+		}
+	case "backtab", "I":
+		if e != nil && len(e.alts) > 0 {
+			e.alt = (e.alt + len(e.alts) - 1) % len(e.alts)
+			ex.refresh()
+		}
+	}
+	ex.cursor = max(0, min(ex.cursor, len(ex.visible)-1))
+	return false
+}
+
+const (
+	styleReset    = "\x1b[0m"
+	styleSelected = "\x1b[7m"
+	styleTag      = "\x1b[30;46m"
+	styleLength   = "\x1b[30;43m"
+	styleValue    = "\x1b[30;42m"
+	styleUnparsed = "\x1b[31m"
+	styleDim      = "\x1b[2m"
+)
+
+func (ex *explorer) bodyHeight() int {
+	return max(1, ex.height-2)
+}
+
+// bytesPerRow is how many bytes the hex pane shows on each row, leaving room for the tree.
+func (ex *explorer) bytesPerRow() int {
+	if ex.width >= 120 {
+		return 16
+	}
+	return 8
+}
+
+// render draws the whole screen as lines of the terminal's width.
+func (ex *explorer) render() []string {
+	body := ex.bodyHeight()
+	bpr := ex.bytesPerRow()
+	hexWidth := 10 + 4*bpr
+	treeWidth := max(10, ex.width-hexWidth-3)
+
+	// keep the selection on screen in both panes
+	if ex.cursor < ex.top {
+		ex.top = ex.cursor
+	}
+	if ex.cursor >= ex.top+body {
+		ex.top = ex.cursor - body + 1
+	}
+	e := ex.selected()
+	if e != nil {
+		first, last := e.node.Offset/bpr, (e.node.End-1)/bpr
+		if first < ex.hexTop || last >= ex.hexTop+body {
+			ex.hexTop = max(0, min(first-2, (len(ex.tree.Input)-1)/bpr-body+1))
+		}
+	}
+
+	lines := []string{fit(fmt.Sprintf("protoid explore: %d bytes, %d fields  (arrows move/expand, tab cycles interpretations, q quits)",
+		len(ex.tree.Input), len(ex.tree.Fields)), ex.width)}
+	for row := 0; row < body; row++ {
+		var left string
+		if i := ex.top + row; i < len(ex.visible) {
+			left = fit(ex.treeLine(ex.visible[i]), treeWidth)
+			if i == ex.cursor {
+				left = styleSelected + left + styleReset
+			}
+		} else {
+			left = strings.Repeat(" ", treeWidth)
+		}
+		lines = append(lines, left+" "+styleDim+"│"+styleReset+" "+ex.hexLine(ex.hexTop+row, e))
+	}
+	lines = append(lines, fit(ex.status(e), ex.width))
+	return lines
+}
+
+// treeLine describes e for the tree pane.
+func (ex *explorer) treeLine(e *entry) string {
+	marker := "  "
+	if e.isMessage() {
+		marker = "▸ "
+		if e.expanded {
+			marker = "▾ "
+		}
+	}
+	c := e.interpretation()
+	value := protoid.FormatValue(c.Value)
+	if c.Kind == protoid.KindMessage {
+		value = "{...}"
+		if e.err != nil {
+			value = "{" + e.err.Error() + "}"
+		}
+	}
+	field := strconv.Itoa(e.node.Field)
+	if e.node.Name != "" {
+		field += " " + e.node.Name
+	}
+	return fmt.Sprintf("%s%s%s %v: %s", strings.Repeat("  ", e.depth), marker, field, c.Kind, value)
+}
+
+// status describes the selected entry in full.
+func (ex *explorer) status(e *entry) string {
+	if e == nil {
+		if ex.tree.Err != nil {
+			return "no fields: " + ex.tree.Err.Error()
+		}
+		return "no fields"
+	}
+	c := e.interpretation()
+	s := fmt.Sprintf("%v  %v  bytes %#x-%#x  interpretation %d/%d: %v %.2f %s", e.path, e.node.WireType, e.node.Offset, e.node.End,
+		e.alt+1, max(1, len(e.alts)), c.Kind, c.Score, c.Reason)
+	if ex.tree.Err != nil {
+		s += fmt.Sprintf("  (unparsed from %#x: %v)", ex.tree.Parsed, ex.tree.Err)
+	}
+	return s
+}
+
+// hexLine draws a row of the hex pane, highlighting the bytes of the selected entry.
+func (ex *explorer) hexLine(row int, e *entry) string {
+	bpr := ex.bytesPerRow()
+	input := ex.tree.Input
+	start := row * bpr
+	if start >= len(input) {
+		return ""
+	}
+
+	// with nothing selected, no byte is in range of the selection
+	tagStart, tagEnd, valueStart, end := -1, -1, -1, -1
+	if e != nil {
+		_, n := binary.Uvarint(input[e.node.Offset:])
+		tagStart, tagEnd, valueStart, end = e.node.Offset, e.node.Offset+n, e.node.ValueOffset, e.node.End
+	}
+
+	var hex, ascii strings.Builder
+	for i := start; i < start+bpr; i++ {
+		if i >= len(input) {
+			hex.WriteString("   ")
+			continue
+		}
+		style := ""
+		switch {
+		case i >= tagStart && i < tagEnd:
+			style = styleTag
+		case i >= tagEnd && i < valueStart:
+			style = styleLength
+		case i >= valueStart && i < end:
+			style = styleValue
+		case i >= ex.tree.Parsed:
+			style = styleUnparsed
+		}
+
+		b := input[i]
+		ch := "."
+		if b >= 0x20 && b < 0x7f {
+			ch = string(b)
+		}
+		if style != "" {
+			fmt.Fprintf(&hex, "%s%02x%s ", style, b, styleReset)
+			ascii.WriteString(style + ch + styleReset)
+		} else {
+			fmt.Fprintf(&hex, "%02x ", b)
+			ascii.WriteString(ch)
+		}
+	}
+	return fmt.Sprintf("%08x  %s%s", start, hex.String(), ascii.String())
+}
+
+// fit truncates or pads s to exactly width characters.
+func fit(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		r := []rune(s)
+		return string(r[:max(0, width-1)]) + "…"
+	}
+	return s + strings.Repeat(" ", width-n)
+}
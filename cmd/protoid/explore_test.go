@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uw-labs/protoid"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestParseKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"up", "q", "pgdown", "backtab", "enter", "esc"}, parseKeys([]byte("\x1b[Aq\x1b[6~\x1b[Z\r\x1b")))
+}
+
+func TestExplorer(t *testing.T) {
+	assert := assert.New(t)
+
+	var inner []byte
+	inner = protowire.AppendTag(inner, 1, protowire.BytesType)
+	inner = protowire.AppendString(inner, "hello")
+	var ser []byte
+	ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+	ser = protowire.AppendBytes(ser, inner)
+	ser = protowire.AppendTag(ser, 2, protowire.VarintType)
+	ser = protowire.AppendVarint(ser, 150)
+
+	ex := newExplorer(protoid.DecodeTree(ser, protoid.Options{}), protoid.Options{})
+	ex.resize(120, 10)
+	assert.Len(ex.visible, 2)
+
+	// expand the embedded message and select its string
+	assert.False(ex.handleKey("right"))
+	assert.Len(ex.visible, 3)
+	ex.handleKey("down")
+	e := ex.selected()
+	assert.Equal(protoid.Path{1, 1}, e.path)
+	assert.Equal(protoid.KindString, e.interpretation().Kind)
+
+	screen := strings.Join(ex.render(), "\n")
+	assert.Contains(screen, `1 string: "hello"`)
+	assert.Contains(screen, styleValue+"68"+styleReset)
+	assert.Contains(screen, styleTag+"0a"+styleReset)
+
+	// cycle through to the raw bytes and back
+	var kinds []protoid.Kind
+	for i := 0; i < len(e.alts); i++ {
+		ex.handleKey("tab")
+		kinds = append(kinds, e.interpretation().Kind)
+	}
+	assert.Contains(kinds, protoid.KindBytes)
+	assert.Contains(kinds, protoid.KindPacked)
+	assert.Equal(protoid.KindString, kinds[len(kinds)-1])
+
+	// reading the embedded message as a string hides its fields
+	ex.handleKey("left")
+	assert.Equal(protoid.Path{1}, ex.selected().path)
+	ex.handleKey("tab")
+	assert.NotEqual(protoid.KindMessage, ex.selected().interpretation().Kind)
+	assert.Len(ex.visible, 2)
+
+	assert.True(ex.handleKey("q"))
+}
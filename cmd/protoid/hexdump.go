@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/uw-labs/protoid"
+	"golang.org/x/term"
+)
+
+func hexdump(args []string) error {
+	fs := flag.NewFlagSet("hexdump", flag.ExitOnError)
+	color := fs.Bool("color", term.IsTerminal(int(os.Stdout.Fd())), "highlight byte ranges with ANSI colours")
+	minConfidence := fs.Float64("min-confidence", 0, "show values interpreted with less confidence than this as raw bytes")
+	schema := addSchemaFlags(fs)
+	fs.Parse(args)
+
+	md, err := schema.messageType()
+	if err != nil {
+		return err
+	}
+	input, err := readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	t := protoid.DecodeTree(input, protoid.Options{MinConfidence: *minConfidence, Type: md})
+	return protoid.WriteHexDump(os.Stdout, t, protoid.HexDumpOptions{Color: *color})
+}
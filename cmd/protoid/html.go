@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+
+	"github.com/uw-labs/protoid"
+)
+
+func html(args []string) error {
+	fs := flag.NewFlagSet("html", flag.ExitOnError)
+	title := fs.String("title", "", "title of the report (default the input file name)")
+	output := fs.String("o", "-", "file to write the report to, or - for standard output")
+	minConfidence := fs.Float64("min-confidence", 0, "show values interpreted with less confidence than this as raw bytes")
+	schema := addSchemaFlags(fs)
+	fs.Parse(args)
+
+	md, err := schema.messageType()
+	if err != nil {
+		return err
+	}
+	input, err := readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	if *title == "" && fs.NArg() == 1 && fs.Arg(0) != "-" {
+		*title = fs.Arg(0)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	t := protoid.DecodeTree(input, protoid.Options{MinConfidence: *minConfidence, Type: md})
+	return protoid.WriteHTML(w, t, protoid.HTMLOptions{Title: *title})
+}
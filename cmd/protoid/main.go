@@ -0,0 +1,139 @@
+// Command protoid inspects protocol buffers messages without their schema.
+//
+// Usage:
+//
+//	protoid <command> [flags] [arguments]
+//
+// Commands that inspect a single message read it from the named file, or from standard input if no file, or "-", is given.  Commands
+// that work on a corpus of messages take files or directories of them.  Run a command with -h for its flags.  The commands are:
+//
+//	check        check that messages conform to their message type
+//	cluster      group a corpus of messages into likely message types by their fields
+//	diff         compare two messages field by field
+//	drift        detect changes in the shape of messages compared to a baseline
+//	explore      browse a message interactively in the terminal
+//	hexdump      print an annotated hex dump of a message
+//	html         write a self-contained HTML report on a message
+//	pseudonymize replace the values in a message with deterministic pseudonyms
+//	redact       remove or mask sensitive fields in a message
+//	stats        report statistics on each field of a corpus of messages
+//	whatis       rank the message types in a descriptor set by how well a message fits them
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/uw-labs/protoid"
+)
+
+// commands maps each subcommand to the function that runs it with the remaining arguments.
+var commands = map[string]func(args []string) error{
+	"check":        check,
+	"cluster":      cluster,
+	"diff":         diff,
+	"drift":        drift,
+	"explore":      explore,
+	"hexdump":      hexdump,
+	"html":         html,
+	"pseudonymize": pseudonymize,
+	"redact":       redact,
+	"stats":        stats,
+	"whatis":       whatis,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "protoid: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd(os.Args[2:]); err != nil {
+		var status exitStatus
+		if errors.As(err, &status) {
+			os.Exit(int(status))
+		}
+		fmt.Fprintf(os.Stderr, "protoid %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// exitStatus can be returned by a command to exit with the given status without printing anything more, for example to report that
+// differences were found.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: protoid <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
+	}
+}
+
+// readInput reads the file named by the only argument in args, or standard input if there isn't one or it is "-".
+func readInput(args []string) ([]byte, error) {
+	switch {
+	case len(args) == 0 || (len(args) == 1 && args[0] == "-"):
+		return io.ReadAll(os.Stdin)
+	case len(args) == 1:
+		return os.ReadFile(args[0])
+	default:
+		return nil, fmt.Errorf("expected at most one file, got %d", len(args))
+	}
+}
+
+// readMessages calls fn with each message at path, which is either a file or a directory of files.  Each file holds a single message,
+// or if delimited is set a stream of messages each prefixed with its length as a varint.  In a stream, the name passed to fn has the
+// index of the message appended in brackets.
+func readMessages(path string, delimited bool, fn func(name string, msg []byte) error) error {
+	return filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if !delimited {
+			msg, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			return fn(name, msg)
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dr := protoid.NewDelimitedReader(f)
+		for i := 0; ; i++ {
+			msg, err := dr.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("%s[%d]: %w", name, i, err)
+			}
+			if err := fn(fmt.Sprintf("%s[%d]", name, i), msg); err != nil {
+				return err
+			}
+		}
+	})
+}
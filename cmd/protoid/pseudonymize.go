@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/uw-labs/protoid"
+)
+
+func pseudonymize(args []string) error {
+	fs := flag.NewFlagSet("pseudonymize", flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "file holding the secret key; use the same key for messages that need to join up")
+	key := fs.String("key", "", "the secret key, if not in a file")
+	output := fs.String("o", "-", "file to write the pseudonymized message to, or - for standard output")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid pseudonymize (-key-file file | -key key) [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var k []byte
+	switch {
+	case *keyFile != "" && *key != "":
+		return errors.New("only one of -key-file and -key can be given")
+	case *keyFile != "":
+		var err error
+		if k, err = os.ReadFile(*keyFile); err != nil {
+			return err
+		}
+	case *key != "":
+		k = []byte(*key)
+	default:
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	input, err := readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	p, err := protoid.Pseudonymize(input, k)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = w.Write(p)
+	return err
+}
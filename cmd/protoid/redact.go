@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/uw-labs/protoid"
+)
+
+func redact(args []string) error {
+	fs := flag.NewFlagSet("redact", flag.ExitOnError)
+	var paths stringList
+	fs.Var(&paths, "path", "path of a field to redact, e.g. 1.3 (repeatable)")
+	heuristics := fs.Bool("heuristics", false, "also redact email addresses, phone numbers and card numbers found in strings and bytes")
+	mask := fs.String("mask", "", "replace redacted values with this instead of removing their fields")
+	output := fs.String("o", "-", "file to write the redacted message to, or - for standard output")
+	verbose := fs.Bool("v", false, "list what was redacted on standard error")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid redact [-path 1.2 ...] [-heuristics] [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := protoid.RedactOptions{Heuristics: *heuristics, Mask: *mask}
+	for _, s := range paths {
+		p, err := protoid.ParsePath(s)
+		if err != nil {
+			return err
+		}
+		opts.Paths = append(opts.Paths, p)
+	}
+	if len(opts.Paths) == 0 && !opts.Heuristics {
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	input, err := readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	redacted, redactions, err := protoid.Redact(input, opts)
+	if err != nil {
+		return err
+	}
+	if *verbose {
+		for _, r := range redactions {
+			fmt.Fprintf(os.Stderr, "redacted %v (%s)\n", r.Path, r.Reason)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = w.Write(redacted)
+	return err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/uw-labs/protoid"
+)
+
+func stats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	delimited := fs.Bool("delimited", false, "read files as streams of length-delimited messages")
+	asJSON := fs.Bool("json", false, "write the report as JSON instead of a table")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid stats [flags] path...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitStatus(2)
+	}
+
+	var s protoid.Stats
+	for _, path := range fs.Args() {
+		err := readMessages(path, *delimited, func(name string, msg []byte) error {
+			if err := s.Add(msg); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	report := s.Report()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Messages int                   `json:"messages"`
+			Fields   []protoid.FieldReport `json:"fields"`
+		}{s.Messages, report})
+	}
+
+	fmt.Printf("%d messages\n\n", s.Messages)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tCOUNT\tPRESENCE\tWIRE TYPES\tKINDS\tPER MESSAGE\tMIN\tMAX\tDISTINCT\tSTRING LENGTHS\tEXAMPLES")
+	for _, r := range report {
+		min, max := "-", "-"
+		if r.Numeric != nil {
+			min, max = fmt.Sprint(r.Numeric.Min), fmt.Sprint(r.Numeric.Max)
+		}
+		distinct := fmt.Sprint(r.Distinct)
+		if r.DistinctCapped {
+			distinct += "+"
+		}
+		var lengths []string
+		for _, b := range r.StringLengths {
+			lengths = append(lengths, fmt.Sprintf("%d-%d:%d", b.Min, b.Max, b.Count))
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Path, r.Count, r.Presence*100, formatCounts(r.WireTypes),
+			formatCounts(r.Kinds), formatCounts(r.Cardinality), min, max, distinct, strings.Join(lengths, " "), strings.Join(r.Examples, ", "))
+	}
+	return tw.Flush()
+}
+
+// formatCounts formats a map of counts as "key:count" pairs, in order of the keys.
+func formatCounts[K ~int](counts map[K]int) string {
+	var keys []K
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%v:%d", k, counts[k])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/uw-labs/protoid"
+)
+
+func whatis(args []string) error {
+	fs := flag.NewFlagSet("whatis", flag.ExitOnError)
+	descriptors := addDescriptorFlags(fs, "the candidate message types")
+	top := fs.Int("top", 10, "number of types to list, or 0 for all of them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: protoid whatis (--descriptor-set file | --proto file.proto) [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files, err := descriptors.load()
+	if err != nil {
+		return err
+	}
+	input, err := readInput(fs.Args())
+	if err != nil {
+		return err
+	}
+	matches, err := protoid.Identify(input, files)
+	if err != nil {
+		return err
+	}
+	if *top > 0 && len(matches) > *top {
+		matches = matches[:*top]
+	}
+	for _, m := range matches {
+		fmt.Printf("%.3f  %s\n", m.Score, m.Type.FullName())
+	}
+	return nil
+}
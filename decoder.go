@@ -0,0 +1,61 @@
+package protoid
+
+import (
+	"context"
+	"sync"
+)
+
+// Decoder decodes messages with a fixed set of options, reusing its internal buffers from one message to the next.  It is safe for
+// concurrent use.  When decoding large numbers of small messages, DecodeInto can also reuse the memory of the result.
+type Decoder struct {
+	opts Options
+	pool sync.Pool
+}
+
+// decodeState is the per-call state of a Decoder, kept in its pool between calls.
+type decodeState struct {
+	w  walker
+	va genericMapValueApplier
+	na nodeValueApplier
+}
+
+// NewDecoder returns a Decoder that uses opts.
+func NewDecoder(opts Options) *Decoder {
+	d := &Decoder{opts: opts}
+	d.pool.New = func() interface{} {
+		return &decodeState{na: nodeValueApplier{opts: opts}}
+	}
+	return d
+}
+
+// Decode is like the package level Decode.  It ignores Options.Type, as the values it returns have nowhere to put field names.
+func (d *Decoder) Decode(input []byte) (map[int]interface{}, error) {
+	st := d.get()
+	defer d.put(st)
+	return st.va.decode(input, &st.w)
+}
+
+// DecodeNodes is like the package level DecodeNodes, using the options of d.
+func (d *Decoder) DecodeNodes(input []byte) ([]*Node, error) {
+	var res Result
+	if err := d.DecodeInto(input, &res); err != nil {
+		return nil, err
+	}
+	return res.Fields, nil
+}
+
+// DecodeInto decodes input into res, replacing whatever it held before and reusing its memory.
+func (d *Decoder) DecodeInto(input []byte, res *Result) error {
+	st := d.get()
+	defer d.put(st)
+	return st.na.decode(context.Background(), input, res, &st.w)
+}
+
+func (d *Decoder) get() *decodeState {
+	return d.pool.Get().(*decodeState)
+}
+
+func (d *Decoder) put(st *decodeState) {
+	st.w.reset()
+	d.pool.Put(st)
+}
@@ -0,0 +1,176 @@
+package protoid
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func decoderTestMessages(t testing.TB) [][]byte {
+	msgs := []proto.Message{
+		&SingleString{TheString: "string123"},
+		&TwoStrings{String_1: "string1", String_2: "string2"},
+		&SingleInt32{TheInt32: -42},
+		&SingleEmbedded{MySingleString: &SingleString{TheString: "123"}},
+		&RepeatedEmbedded{MySingleStrings: []*SingleString{
+			{TheString: "123"}, {TheString: "456"},
+		}},
+	}
+	var out [][]byte
+	for _, m := range msgs {
+		ser, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, ser)
+	}
+	return out
+}
+
+func TestDecoderMatchesDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	d := NewDecoder(Options{})
+	for _, ser := range decoderTestMessages(t) {
+		expected, err := Decode(ser)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := d.Decode(ser)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(expected, actual)
+
+		expectedNodes, err := DecodeNodes(ser, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		actualNodes, err := d.DecodeNodes(ser)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(expectedNodes, actualNodes)
+	}
+}
+
+func TestDecodeIntoReuse(t *testing.T) {
+	assert := assert.New(t)
+
+	d := NewDecoder(Options{})
+	var res Result
+	for _, ser := range decoderTestMessages(t) {
+		if err := d.DecodeInto(ser, &res); err != nil {
+			t.Fatal(err)
+		}
+		expected, err := DecodeNodes(ser, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(expected, res.Fields)
+	}
+
+	assert.Error(d.DecodeInto([]byte{0x0a, 0x05}, &res))
+	assert.Empty(res.Fields)
+}
+
+func TestDecoderConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	msgs := decoderTestMessages(t)
+	var expected []map[int]interface{}
+	var expectedNodes [][]*Node
+	for _, ser := range msgs {
+		m, err := Decode(ser)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, m)
+		nodes, err := DecodeNodes(ser, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedNodes = append(expectedNodes, nodes)
+	}
+	d := NewDecoder(Options{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var res Result
+			for j := 0; j < 100; j++ {
+				k := j % len(msgs)
+				if err := d.DecodeInto(msgs[k], &res); err != nil {
+					t.Error(err)
+					return
+				}
+				m, err := d.Decode(msgs[k])
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				assert.Equal(expectedNodes[k], res.Fields)
+				assert.Equal(expected[k], m)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	msgs := decoderTestMessages(b)
+
+	b.Run("Decode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := Decode(msgs[i%len(msgs)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Decoder.Decode", func(b *testing.B) {
+		d := NewDecoder(Options{})
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := d.Decode(msgs[i%len(msgs)]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("DecodeNodes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := DecodeNodes(msgs[i%len(msgs)], Options{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Decoder.DecodeInto", func(b *testing.B) {
+		d := NewDecoder(Options{})
+		var res Result
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := d.DecodeInto(msgs[i%len(msgs)], &res); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Decoder.DecodeInto/parallel", func(b *testing.B) {
+		d := NewDecoder(Options{})
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			var res Result
+			i := 0
+			for pb.Next() {
+				if err := d.DecodeInto(msgs[i%len(msgs)], &res); err != nil {
+					b.Fatal(err)
+				}
+				i++
+			}
+		})
+	})
+}
@@ -0,0 +1,63 @@
+package protoid
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// DelimitedReader reads a stream of messages that are each prefixed with their length as a varint, as written by the Java
+// writeDelimitedTo and similar functions.
+type DelimitedReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewDelimitedReader returns a DelimitedReader reading from r.
+func NewDelimitedReader(r io.Reader) *DelimitedReader {
+	return &DelimitedReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next message in the stream, or io.EOF at the end of it.  The message is only valid until the next call to Next.
+func (dr *DelimitedReader) Next() ([]byte, error) {
+	size, err := binary.ReadUvarint(dr.r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrUnexpectedEndOfInput
+		}
+		return nil, err
+	}
+	if size > maxDelimitedSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", size)
+	}
+	// The length prefix can't be trusted, so the buffer only grows as the
+	// message actually arrives.
+	dr.buf = dr.buf[:0]
+	for remaining := int(size); remaining > 0; {
+		n := min(remaining, delimitedChunkSize)
+		dr.buf = slices.Grow(dr.buf, n)
+		start := len(dr.buf)
+		dr.buf = dr.buf[:start+n]
+		if _, err := io.ReadFull(dr.r, dr.buf[start:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, ErrUnexpectedEndOfInput
+			}
+			return nil, err
+		}
+		remaining -= n
+	}
+	return dr.buf, nil
+}
+
+// maxDelimitedSize is the largest message DelimitedReader will read, which is the largest protocol buffers allows: 2 GiB - 1.
+const maxDelimitedSize = math.MaxInt32
+
+// delimitedChunkSize is how much DelimitedReader reads of a message at a time.
+const delimitedChunkSize = 1 << 20
@@ -0,0 +1,64 @@
+package protoid
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDelimitedReader(t *testing.T) {
+	assert := assert.New(t)
+
+	var stream []byte
+	for _, msg := range [][]byte{varints(1, 1), {}, varints(2, 300)} {
+		stream = protowire.AppendBytes(stream, msg)
+	}
+
+	dr := NewDelimitedReader(bytes.NewReader(stream))
+	var msgs [][]byte
+	for {
+		msg, err := dr.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(err) {
+			return
+		}
+		msgs = append(msgs, bytes.Clone(msg))
+	}
+	assert.Equal([][]byte{varints(1, 1), {}, varints(2, 300)}, msgs)
+
+	dr = NewDelimitedReader(bytes.NewReader([]byte{0x05, 0x08}))
+	_, err := dr.Next()
+	assert.Equal(ErrUnexpectedEndOfInput, err)
+
+	// a message spanning several reads
+	big := bytes.Repeat([]byte{0x08, 0x01}, delimitedChunkSize)
+	dr = NewDelimitedReader(bytes.NewReader(protowire.AppendBytes(nil, big)))
+	msg, err := dr.Next()
+	assert.NoError(err)
+	assert.Equal(big, msg)
+
+	dr = NewDelimitedReader(bytes.NewReader(protowire.AppendVarint(nil, math.MaxInt32+1)))
+	_, err = dr.Next()
+	assert.Error(err)
+}
+
+func TestDelimitedReaderHugePrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	// a prefix claiming the largest message allowed, followed by very little
+	stream := append(protowire.AppendVarint(nil, math.MaxInt32), 0x08, 0x01)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := NewDelimitedReader(bytes.NewReader(stream)).Next()
+	runtime.ReadMemStats(&after)
+
+	assert.Equal(ErrUnexpectedEndOfInput, err)
+	assert.Less(after.TotalAlloc-before.TotalAlloc, uint64(16<<20))
+}
@@ -0,0 +1,34 @@
+package protoid
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// LoadDescriptorSet parses a serialized FileDescriptorSet, as written by protoc --descriptor_set_out or buf build -o.  Every
+// dependency of the files in the set must be in it too, as with protoc --include_imports.
+func LoadDescriptorSet(data []byte) (*protoregistry.Files, error) {
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &fds); err != nil {
+		return nil, fmt.Errorf("parsing descriptor set: %w", err)
+	}
+	return protodesc.NewFiles(&fds)
+}
+
+// FindMessage looks up the message type with the given full name, such as "pkg.Msg", in files.
+func FindMessage(files *protoregistry.Files, name string) (protoreflect.MessageDescriptor, error) {
+	d, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("finding %s: %w", name, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message type", name)
+	}
+	return md, nil
+}
@@ -0,0 +1,39 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestLoadDescriptorSet(t *testing.T) {
+	assert := assert.New(t)
+
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(descriptorOf(&SingleString{}).ParentFile()),
+	}}
+	data, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := LoadDescriptorSet(data)
+	if !assert.NoError(err) {
+		return
+	}
+	md, err := FindMessage(files, "protoid.RepeatedEmbedded")
+	assert.NoError(err)
+	assert.Equal(protoreflect.FullName("protoid.RepeatedEmbedded"), md.FullName())
+
+	_, err = FindMessage(files, "protoid.TestEnum")
+	assert.EqualError(err, "protoid.TestEnum is not a message type")
+	_, err = FindMessage(files, "protoid.Missing")
+	assert.Error(err)
+
+	_, err = LoadDescriptorSet([]byte{0x0a})
+	assert.Error(err)
+}
@@ -0,0 +1,378 @@
+package protoid
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// DiffOptions controls what Diff counts as a difference.
+type DiffOptions struct {
+	// IgnoreOrder compares the fields of each message regardless of the order they appear in, and matches up the elements of repeated
+	// fields as a multiset rather than by index.
+	IgnoreOrder bool
+	// IgnorePacking treats a packed repeated field as equal to the same values written unpacked.  A length-delimited field is only read
+	// as packed if the same field number also appears unpacked, as otherwise there's no telling what type its elements are.
+	IgnorePacking bool
+	// IgnoreEncoding ignores differences in how equal values are encoded, such as varints padded with redundant continuation bytes.
+	IgnoreEncoding bool
+}
+
+// ChangeType is the kind of a difference found by Diff.
+type ChangeType int
+
+const (
+	// Added is a field that is only in the second message.
+	Added ChangeType = iota
+	// Removed is a field that is only in the first message.
+	Removed
+	// Changed is a field whose value differs.
+	Changed
+	// Reordered is a message whose fields appear in a different order.  Old and New hold the field numbers in the order they appear.
+	Reordered
+	// Reencoded is a field with the same value, encoded differently.  Old and New hold the encoded bytes.
+	Reencoded
+)
+
+var changeTypeNames = map[ChangeType]string{
+	Added:     "added",
+	Removed:   "removed",
+	Changed:   "changed",
+	Reordered: "reordered",
+	Reencoded: "reencoded",
+}
+
+func (ct ChangeType) String() string {
+	if name, ok := changeTypeNames[ct]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Change is a single difference found by Diff.  Path is made up of the field numbers leading to the field separated by dots, with the
+// index of the element in brackets for fields that occur more than once, e.g. "3[1].2".  It is empty for the outermost message.  Old and
+// New hold the interpreted values, as returned by Decode, where there are any.
+type Change struct {
+	Type ChangeType
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "(root)"
+	}
+	switch c.Type {
+	case Added:
+		return fmt.Sprintf("+ %s: %s", path, FormatValue(c.New))
+	case Removed:
+		return fmt.Sprintf("- %s: %s", path, FormatValue(c.Old))
+	case Reordered:
+		return fmt.Sprintf("~ %s: field order %v -> %v", path, c.Old, c.New)
+	case Reencoded:
+		return fmt.Sprintf("~ %s: encoding %x -> %x", path, c.Old, c.New)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", path, FormatValue(c.Old), FormatValue(c.New))
+	}
+}
+
+// Diff compares two messages field by field, descending into embedded messages, and returns the differences ordered by field number
+// at each level.  Fields are matched up by their path of field numbers, with the elements of repeated fields matched by index unless
+// opts.IgnoreOrder is set.  An error is returned if either message is malformed.
+func Diff(a, b []byte, opts DiffOptions) ([]Change, error) {
+	fa, err := diffFields(a)
+	if err != nil {
+		return nil, fmt.Errorf("first message: %w", err)
+	}
+	fb, err := diffFields(b)
+	if err != nil {
+		return nil, fmt.Errorf("second message: %w", err)
+	}
+	d := differ{opts: opts}
+	d.messages("", fa, fb)
+	return d.changes, nil
+}
+
+// diffField is a field being compared by Diff.
+type diffField struct {
+	num      int
+	wiretype WireType
+	value    uint64
+	data     []byte
+	head     []byte // the tag and any length prefix as encoded, or nil for an element of a packed field
+	raw      []byte // the value as encoded
+}
+
+// diffFields scans the fields of data for Diff.
+func diffFields(data []byte) ([]diffField, error) {
+	spans, err := scanFields(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	return spansToDiffFields(data, spans), nil
+}
+
+func spansToDiffFields(data []byte, spans []fieldSpan) []diffField {
+	fields := make([]diffField, len(spans))
+	for i, s := range spans {
+		fields[i] = diffField{
+			num:      s.num,
+			wiretype: s.wiretype,
+			value:    s.value,
+			data:     s.data,
+			head:     data[s.off:s.valueOff],
+			raw:      data[s.valueOff:s.end],
+		}
+	}
+	return fields
+}
+
+// embedded returns the fields of f if it looks like an embedded message.
+func (f diffField) embedded() ([]diffField, bool) {
+	if f.wiretype != WireBytes {
+		return nil, false
+	}
+	spans, err := scanFields(f.data, nil)
+	var buf [3]Candidate
+	if bytesCandidates(f.data, spans, err == nil, buf[:0])[0].Kind != KindMessage {
+		return nil, false
+	}
+	return spansToDiffFields(f.data, spans), true
+}
+
+// interpreted returns the most plausible value of f, as Decode would.
+func (f diffField) interpreted() interface{} {
+	switch f.wiretype {
+	case WireVarint:
+		return InterpretVarint(f.value)[0].Value
+	case WireFixed64:
+		return InterpretFixed64(f.value)[0].Value
+	case WireFixed32:
+		return InterpretFixed32(uint32(f.value))[0].Value
+	default:
+		return InterpretBytes(f.data)[0].Value
+	}
+}
+
+type differ struct {
+	opts    DiffOptions
+	changes []Change
+}
+
+// messages compares the fields of two messages at path.
+func (d *differ) messages(path string, a, b []diffField) {
+	if !d.opts.IgnoreOrder {
+		d.order(path, a, b)
+	}
+
+	byNum := func(fields []diffField) map[int][]diffField {
+		m := make(map[int][]diffField)
+		for _, f := range fields {
+			m[f.num] = append(m[f.num], f)
+		}
+		return m
+	}
+	ga, gb := byNum(a), byNum(b)
+	var nums []int
+	for num := range ga {
+		nums = append(nums, num)
+	}
+	for num := range gb {
+		if _, ok := ga[num]; !ok {
+			nums = append(nums, num)
+		}
+	}
+	slices.Sort(nums)
+
+	for _, num := range nums {
+		as, bs := ga[num], gb[num]
+		if d.opts.IgnorePacking {
+			as, bs = unpackFields(as, bs)
+		}
+		d.repeated(fieldPath(path, num), as, bs)
+	}
+}
+
+// order reports a message whose fields are in a different order.  Fields that are only in one of the messages are left out, as they
+// are reported separately.
+func (d *differ) order(path string, a, b []diffField) {
+	ca, cb := map[int]int{}, map[int]int{}
+	for _, f := range a {
+		ca[f.num]++
+	}
+	for _, f := range b {
+		cb[f.num]++
+	}
+	project := func(fields []diffField) []int {
+		seen := map[int]int{}
+		var nums []int
+		for _, f := range fields {
+			if seen[f.num] < min(ca[f.num], cb[f.num]) {
+				seen[f.num]++
+				nums = append(nums, f.num)
+			}
+		}
+		return nums
+	}
+	if pa, pb := project(a), project(b); !slices.Equal(pa, pb) {
+		d.changes = append(d.changes, Change{Type: Reordered, Path: path, Old: pa, New: pb})
+	}
+}
+
+// repeated compares every occurrence of a field.
+func (d *differ) repeated(path string, as, bs []diffField) {
+	elemPath := func(i int) string {
+		if len(as) > 1 || len(bs) > 1 {
+			return path + "[" + strconv.Itoa(i) + "]"
+		}
+		return path
+	}
+
+	// the indices of the elements still to be compared
+	ia, ib := make([]int, len(as)), make([]int, len(bs))
+	for i := range ia {
+		ia[i] = i
+	}
+	for i := range ib {
+		ib[i] = i
+	}
+
+	if d.opts.IgnoreOrder {
+		// drop the elements that are equal to one in the other message
+		var ra []int
+		for _, i := range ia {
+			j := slices.IndexFunc(ib, func(j int) bool { return d.equal(as[i], bs[j]) })
+			if j < 0 {
+				ra = append(ra, i)
+				continue
+			}
+			ib = slices.Delete(ib, j, j+1)
+		}
+		ia = ra
+	}
+
+	n := min(len(ia), len(ib))
+	for k := 0; k < n; k++ {
+		d.field(elemPath(ia[k]), as[ia[k]], bs[ib[k]])
+	}
+	for _, i := range ia[n:] {
+		d.changes = append(d.changes, Change{Type: Removed, Path: elemPath(i), Old: as[i].interpreted()})
+	}
+	for _, i := range ib[n:] {
+		d.changes = append(d.changes, Change{Type: Added, Path: elemPath(i), New: bs[i].interpreted()})
+	}
+}
+
+// equal returns whether there are no differences between two fields.
+func (d *differ) equal(a, b diffField) bool {
+	sub := differ{opts: d.opts}
+	sub.field("", a, b)
+	return len(sub.changes) == 0
+}
+
+// field compares a single occurrence of a field.
+func (d *differ) field(path string, a, b diffField) {
+	if a.wiretype != b.wiretype {
+		d.changes = append(d.changes, Change{Type: Changed, Path: path, Old: a.interpreted(), New: b.interpreted()})
+		return
+	}
+
+	if a.wiretype == WireBytes {
+		ea, aok := a.embedded()
+		eb, bok := b.embedded()
+		if aok && bok {
+			n := len(d.changes)
+			d.messages(path, ea, eb)
+			if len(d.changes) == n {
+				d.encoding(path, a, b)
+			}
+			return
+		}
+		if !bytes.Equal(a.data, b.data) {
+			d.changes = append(d.changes, Change{Type: Changed, Path: path, Old: a.interpreted(), New: b.interpreted()})
+			return
+		}
+	} else if a.value != b.value {
+		d.changes = append(d.changes, Change{Type: Changed, Path: path, Old: a.interpreted(), New: b.interpreted()})
+		return
+	}
+	d.encoding(path, a, b)
+}
+
+// encoding reports two fields with equal values that are encoded differently.
+func (d *differ) encoding(path string, a, b diffField) {
+	if d.opts.IgnoreEncoding {
+		return
+	}
+	if a.head == nil || b.head == nil {
+		// an element of a packed field doesn't have a tag of its own
+		if !bytes.Equal(a.raw, b.raw) {
+			d.changes = append(d.changes, Change{Type: Reencoded, Path: path, Old: a.raw, New: b.raw})
+		}
+		return
+	}
+	if !bytes.Equal(a.head, b.head) || (a.wiretype != WireBytes && !bytes.Equal(a.raw, b.raw)) {
+		encA := append(a.head[:len(a.head):len(a.head)], a.raw...)
+		encB := append(b.head[:len(b.head):len(b.head)], b.raw...)
+		d.changes = append(d.changes, Change{Type: Reencoded, Path: path, Old: encA, New: encB})
+	}
+}
+
+// unpackFields splits any packed occurrences of a field into their elements, if the field also occurs unpacked in either message.
+func unpackFields(as, bs []diffField) ([]diffField, []diffField) {
+	wiretype := WireBytes
+	for _, f := range slices.Concat(as, bs) {
+		if f.wiretype != WireBytes {
+			wiretype = f.wiretype
+			break
+		}
+	}
+	if wiretype == WireBytes {
+		return as, bs
+	}
+	return unpack(as, wiretype), unpack(bs, wiretype)
+}
+
+// unpack replaces the length-delimited fields in fields with the elements they hold, if they can be read as packed values of the
+// given wire type.
+func unpack(fields []diffField, wiretype WireType) []diffField {
+	var out []diffField
+	for _, f := range fields {
+		if f.wiretype != WireBytes {
+			out = append(out, f)
+			continue
+		}
+		var elems []diffField
+		r := reader{buf: f.data}
+		for !r.done() {
+			start := r.off
+			e := diffField{num: f.num, wiretype: wiretype}
+			switch wiretype {
+			case WireVarint:
+				e.value = r.decodeVarint()
+			case WireFixed64:
+				e.value = r.readLeUint64()
+			case WireFixed32:
+				e.value = uint64(r.readLeUint32())
+			}
+			e.raw = f.data[start:r.off]
+			elems = append(elems, e)
+		}
+		if r.err != nil {
+			out = append(out, f)
+			continue
+		}
+		out = append(out, elems...)
+	}
+	return out
+}
+
+func fieldPath(parent string, num int) string {
+	if parent == "" {
+		return strconv.Itoa(num)
+	}
+	return parent + "." + strconv.Itoa(num)
+}
@@ -0,0 +1,109 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func varints(num protowire.Number, values ...uint64) []byte {
+	var ser []byte
+	for _, v := range values {
+		ser = protowire.AppendTag(ser, num, protowire.VarintType)
+		ser = protowire.AppendVarint(ser, v)
+	}
+	return ser
+}
+
+func TestDiff(t *testing.T) {
+	assert := assert.New(t)
+
+	marshal := func(m proto.Message) []byte {
+		ser, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ser
+	}
+
+	a := marshal(&RepeatedEmbedded{MySingleStrings: []*SingleString{{TheString: "123"}, {TheString: "456"}}})
+	changes, err := Diff(a, a, DiffOptions{})
+	assert.NoError(err)
+	assert.Empty(changes)
+
+	b := marshal(&RepeatedEmbedded{MySingleStrings: []*SingleString{{TheString: "123"}, {TheString: "789"}, {TheString: "0"}}})
+	changes, err = Diff(a, b, DiffOptions{})
+	assert.NoError(err)
+	assert.Equal([]Change{
+		{Type: Changed, Path: "1[1].1", Old: "456", New: "789"},
+		{Type: Added, Path: "1[2]", New: map[int]interface{}{1: "0"}},
+	}, changes)
+	assert.Equal(`~ 1[1].1: "456" -> "789"`, changes[0].String())
+
+	changes, err = Diff(b, a, DiffOptions{})
+	assert.NoError(err)
+	assert.Equal(Change{Type: Removed, Path: "1[2]", Old: map[int]interface{}{1: "0"}}, changes[1])
+
+	_, err = Diff(a, []byte{0x0a}, DiffOptions{})
+	assert.Error(err)
+}
+
+func TestDiffOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	a := append(varints(1, 5), varints(2, 6)...)
+	b := append(varints(2, 6), varints(1, 5)...)
+	changes, err := Diff(a, b, DiffOptions{})
+	assert.NoError(err)
+	assert.Equal([]Change{{Type: Reordered, Path: "", Old: []int{1, 2}, New: []int{2, 1}}}, changes)
+	assert.Equal("~ (root): field order [1 2] -> [2 1]", changes[0].String())
+
+	changes, err = Diff(varints(1, 1, 2, 3), varints(1, 3, 1, 2), DiffOptions{})
+	assert.NoError(err)
+	assert.Len(changes, 3)
+	assert.Equal("1[0]", changes[0].Path)
+
+	changes, err = Diff(varints(1, 1, 2, 3), varints(1, 3, 1, 2), DiffOptions{IgnoreOrder: true})
+	assert.NoError(err)
+	assert.Empty(changes)
+
+	changes, err = Diff(varints(1, 1, 2, 3), varints(1, 3, 4, 2), DiffOptions{IgnoreOrder: true})
+	assert.NoError(err)
+	assert.Equal([]Change{{Type: Changed, Path: "1[0]", Old: uint64(1), New: uint64(4)}}, changes)
+}
+
+func TestDiffPacking(t *testing.T) {
+	assert := assert.New(t)
+
+	packed, err := proto.Marshal(&RepeatedInt32{MyInt32S: []int32{1, 150, 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked := varints(1, 1, 150, 3)
+
+	changes, err := Diff(packed, unpacked, DiffOptions{})
+	assert.NoError(err)
+	assert.NotEmpty(changes)
+
+	changes, err = Diff(packed, unpacked, DiffOptions{IgnorePacking: true})
+	assert.NoError(err)
+	assert.Empty(changes)
+}
+
+func TestDiffEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	canonical := []byte{0x08, 0x96, 0x01}
+	padded := []byte{0x08, 0x96, 0x81, 0x00}
+
+	changes, err := Diff(canonical, padded, DiffOptions{})
+	assert.NoError(err)
+	assert.Equal([]Change{{Type: Reencoded, Path: "1", Old: canonical, New: padded}}, changes)
+	assert.Equal("~ 1: encoding 089601 -> 08968100", changes[0].String())
+
+	changes, err = Diff(canonical, padded, DiffOptions{IgnoreEncoding: true})
+	assert.NoError(err)
+	assert.Empty(changes)
+}
@@ -0,0 +1,136 @@
+package protoid
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DriftType is the kind of change found by DetectDrift.
+type DriftType int
+
+const (
+	// NewField is a field that never appeared in the baseline.
+	NewField DriftType = iota
+	// MissingField is a field from the baseline that no longer appears.
+	MissingField
+	// WireTypeChanged is a field that has been seen with a wire type it never had in the baseline.
+	WireTypeChanged
+	// KindChanged is a field whose most common interpretation has changed.
+	KindChanged
+)
+
+var driftTypeNames = map[DriftType]string{
+	NewField:        "new-field",
+	MissingField:    "missing-field",
+	WireTypeChanged: "wire-type-changed",
+	KindChanged:     "kind-changed",
+}
+
+func (dt DriftType) String() string {
+	if name, ok := driftTypeNames[dt]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Drift is a single difference between the shape of a baseline set of messages and that of newer messages.  Breaking is set for
+// changes that are likely to break consumers of the messages: a field that was in every baseline message disappearing, a field changing
+// wire type, or a field changing between an embedded message and something else.
+type Drift struct {
+	Type     DriftType
+	Path     string
+	Breaking bool
+	Detail   string
+}
+
+func (d Drift) String() string {
+	s := fmt.Sprintf("%s %s: %s", d.Type, d.Path, d.Detail)
+	if d.Breaking {
+		s += " (breaking)"
+	}
+	return s
+}
+
+// DetectDrift compares the shape of newer messages with a baseline built from historical ones, returning the differences ordered by
+// path.  A field is only reported missing if it doesn't appear in any of the newer messages, as optional fields come and go.
+func DetectDrift(baseline, current *Shape) []Drift {
+	var drifts []Drift
+	for path, cur := range current.Fields {
+		base, ok := baseline.Fields[path]
+		if !ok {
+			if parent, ok := parentPath(path); ok && baseline.Fields[parent] == nil {
+				// the whole message is new
+				continue
+			}
+			drifts = append(drifts, Drift{Type: NewField, Path: path,
+				Detail: fmt.Sprintf("seen in %d of %d messages as %v", cur.Seen, current.Samples, dominant(cur.Kinds))})
+			continue
+		}
+
+		for wt, n := range cur.WireTypes {
+			if base.WireTypes[wt] == 0 {
+				drifts = append(drifts, Drift{Type: WireTypeChanged, Path: path, Breaking: true,
+					Detail: fmt.Sprintf("%v seen %d times, was %v", wt, n, dominant(base.WireTypes))})
+			}
+		}
+
+		if bk, ck := dominant(base.Kinds), dominant(cur.Kinds); bk != ck {
+			drifts = append(drifts, Drift{Type: KindChanged, Path: path, Breaking: bk == KindMessage || ck == KindMessage,
+				Detail: fmt.Sprintf("mostly %v, was %v", ck, bk)})
+		}
+	}
+
+	for path, base := range baseline.Fields {
+		if _, ok := current.Fields[path]; ok {
+			continue
+		}
+		if parent, ok := parentPath(path); ok && (current.Fields[parent] == nil || current.Fields[parent].Kinds[KindMessage] == 0) {
+			// the whole message is missing, or no longer a message
+			continue
+		}
+		drifts = append(drifts, Drift{Type: MissingField, Path: path, Breaking: base.Seen == baseline.Samples,
+			Detail: fmt.Sprintf("in none of %d messages, was in %d of %d", current.Samples, base.Seen, baseline.Samples)})
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if c := comparePaths(drifts[i].Path, drifts[j].Path); c != 0 {
+			return c < 0
+		}
+		return drifts[i].Type < drifts[j].Type
+	})
+	return drifts
+}
+
+// HasBreakingDrift returns whether any of drifts is breaking.
+func HasBreakingDrift(drifts []Drift) bool {
+	for _, d := range drifts {
+		if d.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// parentPath returns the path of the message containing the field at path, if it is in an embedded message.
+func parentPath(path string) (string, bool) {
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return "", false
+	}
+	return path[:i], true
+}
+
+// comparePaths orders paths as given by Path.String by their field numbers.
+func comparePaths(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, _ := strconv.Atoi(pa[i])
+		nb, _ := strconv.Atoi(pb[i])
+		if na != nb {
+			return na - nb
+		}
+	}
+	return len(pa) - len(pb)
+}
@@ -0,0 +1,97 @@
+package protoid
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func buildShape(t *testing.T, msgs ...[]byte) *Shape {
+	var s Shape
+	for _, msg := range msgs {
+		if err := s.Add(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &s
+}
+
+func TestShape(t *testing.T) {
+	assert := assert.New(t)
+
+	s := buildShape(t, varints(1, 1, 2), varints(1, 3), varints(2, 4))
+	assert.Equal(3, s.Samples)
+	assert.Equal(&FieldStats{Seen: 2, Repeated: 1, WireTypes: map[WireType]int{WireVarint: 3}, Kinds: map[Kind]int{KindUnsigned: 3}}, s.Fields["1"])
+
+	ser, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(string(ser), `"wire_types":{"varint":3}`)
+	var loaded Shape
+	assert.NoError(json.Unmarshal(ser, &loaded))
+	assert.Equal(s, &loaded)
+}
+
+func TestShapeMaps(t *testing.T) {
+	assert := assert.New(t)
+
+	entry := func(key string, value uint64) []byte {
+		inner := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte(key))
+		inner = append(inner, varints(2, value)...)
+		return protowire.AppendBytes(protowire.AppendTag(nil, 4, protowire.BytesType), inner)
+	}
+
+	s := buildShape(t, slices.Concat(entry("apples", 3), entry("pears", 1<<40)), slices.Concat(entry("plums", 7), entry("figs", 2)))
+	mapType, ok := s.Fields["4"].MapType()
+	assert.True(ok)
+	assert.Equal("map<string,uint64>", mapType)
+
+	// a single entry can't be told from an ordinary embedded message
+	s = buildShape(t, slices.Concat(entry("apples", 3), entry("pears", 1)), entry("plums", 7))
+	_, ok = s.Fields["4"].MapType()
+	assert.False(ok)
+	assert.Equal(1, s.Fields["4"].Maps)
+}
+
+func TestDetectDrift(t *testing.T) {
+	assert := assert.New(t)
+
+	embedded := func(inner []byte) []byte {
+		var ser []byte
+		ser = protowire.AppendTag(ser, 3, protowire.BytesType)
+		return protowire.AppendBytes(ser, inner)
+	}
+	str := func(num protowire.Number, s string) []byte {
+		ser := protowire.AppendTag(nil, num, protowire.BytesType)
+		return protowire.AppendString(ser, s)
+	}
+
+	baseline := buildShape(t,
+		slices.Concat(varints(1, 1), str(2, "a"), embedded(varints(1, 5))),
+		slices.Concat(varints(1, 2), embedded(varints(1, 6))),
+	)
+
+	assert.Empty(DetectDrift(baseline, baseline))
+
+	var f1 []byte
+	f1 = protowire.AppendTag(f1, 1, protowire.Fixed32Type)
+	f1 = protowire.AppendFixed32(f1, 7)
+	current := buildShape(t,
+		slices.Concat(f1, str(3, "not a message"), varints(10, 1)),
+		slices.Concat(varints(1, 3), str(3, "nor this")),
+	)
+
+	drifts := DetectDrift(baseline, current)
+	assert.Equal([]Drift{
+		{Type: WireTypeChanged, Path: "1", Breaking: true, Detail: "fixed32 seen 1 times, was varint"},
+		{Type: MissingField, Path: "2", Breaking: false, Detail: "in none of 2 messages, was in 1 of 2"},
+		{Type: KindChanged, Path: "3", Breaking: true, Detail: "mostly string, was message"},
+		{Type: NewField, Path: "10", Breaking: false, Detail: "seen in 1 of 2 messages as unsigned"},
+	}, drifts)
+	assert.True(HasBreakingDrift(drifts))
+	assert.Equal("wire-type-changed 1: fixed32 seen 1 times, was varint (breaking)", drifts[0].String())
+}
@@ -0,0 +1,62 @@
+package protoid
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFormattedLen is roughly how many characters of a string, bytes or packed value FormatValue will show.
+const maxFormattedLen = 48
+
+// FormatValue describes a decoded value in a single short line, as shown by WriteHexDump.  Long strings and bytes are truncated.
+func FormatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if utf8.RuneCountInString(v) > maxFormattedLen {
+			r := []rune(v)
+			return strconv.Quote(string(r[:maxFormattedLen])) + "..."
+		}
+		return strconv.Quote(v)
+	case []byte:
+		if len(v) > maxFormattedLen/2 {
+			return fmt.Sprintf("%x... (%d bytes)", v[:maxFormattedLen/2], len(v))
+		}
+		return fmt.Sprintf("%x (%d bytes)", v, len(v))
+	case Packed:
+		var b strings.Builder
+		fmt.Fprintf(&b, "%v [", v.WireType)
+		for i, e := range v.Values {
+			if b.Len() > maxFormattedLen {
+				fmt.Fprintf(&b, " ... %d more", len(v.Values)-i)
+				break
+			}
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			if v.Declared != nil {
+				fmt.Fprint(&b, v.Declared[i])
+				continue
+			}
+			b.WriteString(strconv.FormatUint(e, 10))
+		}
+		b.WriteByte(']')
+		return b.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formatValue describes the value of n for the formatters.
+func formatValue(n *Node) string {
+	if n.Kind == KindMessage {
+		return fmt.Sprintf("message, %d fields", len(n.Children))
+	}
+	return FormatValue(n.Value)
+}
+
+// formatGuess describes how protoid arrived at the value of n.
+func formatGuess(n *Node) string {
+	return fmt.Sprintf("%v %.2f %s", n.Kind, n.Confidence, n.Reason)
+}
@@ -0,0 +1,103 @@
+package protoid
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// HexDumpOptions controls WriteHexDump.
+type HexDumpOptions struct {
+	// Color highlights the role of each byte range with ANSI escape codes, and unparsed bytes in red.
+	Color bool
+}
+
+const (
+	hexDumpRowLen = 16
+
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// WriteHexDump writes an xxd style hex dump of t to w, with each byte range labelled with its role (tag, length prefix or value), the
+// path of its field and its interpreted value.  The fields of embedded messages are indented beneath them, and any bytes that couldn't
+// be parsed are marked with "!!".
+func WriteHexDump(w io.Writer, t *Tree, opts HexDumpOptions) error {
+	hd := hexDumper{w: bufio.NewWriter(w), input: t.Input, opts: opts}
+	hd.nodes(t.Fields, nil, 0)
+	if t.Parsed < len(t.Input) {
+		label := "!!"
+		if t.Err != nil {
+			label += " " + t.Err.Error()
+		}
+		hd.row(t.Parsed, len(t.Input), "unparsed", label, 0, ansiRed)
+	}
+	return hd.w.Flush()
+}
+
+type hexDumper struct {
+	w     *bufio.Writer
+	input []byte
+	opts  HexDumpOptions
+}
+
+func (hd *hexDumper) nodes(nodes []*Node, path Path, depth int) {
+	for _, n := range nodes {
+		p := append(path, n.Field)
+
+		// the tag is the varint at the start of the field
+		r := reader{buf: hd.input[n.Offset:n.End]}
+		r.decodeVarint()
+		tagEnd := n.Offset + r.off
+
+		label := fmt.Sprintf("%v %v", p, n.WireType)
+		if n.Name != "" {
+			label = fmt.Sprintf("%v %s %v", p, n.Name, n.WireType)
+		}
+		hd.row(n.Offset, tagEnd, "tag", label, depth, ansiCyan)
+		if n.WireType == WireBytes {
+			hd.row(tagEnd, n.ValueOffset, "length", fmt.Sprintf("%d bytes", n.End-n.ValueOffset), depth, ansiYellow)
+		}
+		if n.Kind == KindMessage {
+			hd.nodes(n.Children, p, depth+1)
+			continue
+		}
+		hd.row(n.ValueOffset, n.End, "value", fmt.Sprintf("%s (%s)", formatValue(n), formatGuess(n)), depth, ansiGreen)
+	}
+}
+
+// row writes the bytes from start to end, wrapping them over as many lines as they need, with the label on the first line.
+func (hd *hexDumper) row(start, end int, role, label string, depth int, color string) {
+	if start == end {
+		// an empty embedded message or string has no value bytes
+		return
+	}
+	indent := strings.Repeat("  ", depth)
+	for off := start; off < end; off += hexDumpRowLen {
+		data := hd.input[off:min(off+hexDumpRowLen, end)]
+
+		var hex, ascii strings.Builder
+		for _, b := range data {
+			fmt.Fprintf(&hex, "%02x ", b)
+			if b >= 0x20 && b < 0x7f {
+				ascii.WriteByte(b)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+
+		line := fmt.Sprintf("%08x  %-48s |%-16s|", off, hex.String(), ascii.String())
+		if off == start {
+			line += fmt.Sprintf("  %s%-6s %s", indent, role, label)
+		}
+		if hd.opts.Color {
+			line = color + line + ansiReset
+		}
+		hd.w.WriteString(line)
+		hd.w.WriteByte('\n')
+	}
+}
@@ -0,0 +1,65 @@
+package protoid
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTreeMalformed(t *testing.T) {
+	assert := assert.New(t)
+
+	ser, err := proto.Marshal(&TwoStrings{String_1: "string1", String_2: "string2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := ser[:len(ser)-3]
+
+	tree := DecodeTree(truncated, Options{})
+	assert.Equal(ErrUnexpectedEndOfInput, tree.Err)
+	assert.Equal(9, tree.Parsed)
+	if assert.Len(tree.Fields, 1) {
+		assert.Equal("string1", tree.Fields[0].Value)
+	}
+
+	tree = DecodeTree(ser, Options{})
+	assert.NoError(tree.Err)
+	assert.Equal(len(ser), tree.Parsed)
+	assert.Len(tree.Fields, 2)
+}
+
+func TestWriteHexDump(t *testing.T) {
+	assert := assert.New(t)
+
+	ser, err := proto.Marshal(&SingleEmbedded{MySingleString: &SingleString{TheString: "a string that is longer than one row"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ser = append(ser, 0x0a, 0x05)
+
+	var buf bytes.Buffer
+	if err := WriteHexDump(&buf, DecodeTree(ser, Options{}), HexDumpOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	assert.Equal([]string{
+		"00000000  0a                                               |.               |  tag    1 length-delimited",
+		"00000001  26                                               |&               |  length 38 bytes",
+		"00000002  0a                                               |.               |    tag    1.1 length-delimited",
+		"00000003  24                                               |$               |    length 36 bytes",
+		`00000004  61 20 73 74 72 69 6e 67 20 74 68 61 74 20 69 73  |a string that is|    value  "a string that is longer than one row" (string 0.85 printable-utf8)`,
+		"00000014  20 6c 6f 6e 67 65 72 20 74 68 61 6e 20 6f 6e 65  | longer than one|",
+		"00000024  20 72 6f 77                                      | row            |",
+		"00000028  0a 05                                            |..              |  unparsed !! unexpected end of input",
+	}, lines)
+
+	buf.Reset()
+	if err := WriteHexDump(&buf, DecodeTree(ser, Options{}), HexDumpOptions{Color: true}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(buf.String(), ansiRed+"00000028")
+}
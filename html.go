@@ -0,0 +1,231 @@
+package protoid
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// HTMLOptions controls WriteHTML.
+type HTMLOptions struct {
+	// Title is shown at the top of the report.  It defaults to "protoid report".
+	Title string
+}
+
+// ambiguityMargin is how close to the best guess an alternative interpretation has to score for the HTML report to point it out.
+const ambiguityMargin = 0.25
+
+// WriteHTML writes a report on t to w as a single HTML page with no external assets, so that it can be attached to a ticket and opened
+// offline.  The page shows the decoded fields as a tree next to a hex view of the input; hovering over either highlights the matching
+// part of the other.  Fields whose interpretation is doubtful are listed with their plausible alternatives.
+func WriteHTML(w io.Writer, t *Tree, opts HTMLOptions) error {
+	r := htmlReport{Title: opts.Title, Size: len(t.Input), Parsed: t.Parsed}
+	if r.Title == "" {
+		r.Title = "protoid report"
+	}
+	if t.Err != nil {
+		r.Err = t.Err.Error()
+	}
+
+	owner := make([]int, len(t.Input)) // the innermost field each byte belongs to
+	for i := range owner {
+		owner[i] = -1
+	}
+	r.Fields = r.addFields(t.Input, t.Fields, nil, owner)
+
+	for off := 0; off < len(t.Input); off += hexDumpRowLen {
+		row := htmlRow{Offset: fmt.Sprintf("%08x", off)}
+		for i := off; i < min(off+hexDumpRowLen, len(t.Input)); i++ {
+			b := htmlByte{Offset: i, Hex: fmt.Sprintf("%02x", t.Input[i]), Char: ".", Field: owner[i]}
+			if c := t.Input[i]; c >= 0x20 && c < 0x7f {
+				b.Char = string(c)
+			}
+			switch {
+			case i >= t.Parsed:
+				b.Role = "unparsed"
+			case owner[i] >= 0:
+				b.Role = r.roleOf(i, owner[i])
+			}
+			row.Bytes = append(row.Bytes, b)
+		}
+		r.Rows = append(r.Rows, row)
+	}
+
+	return htmlTemplate.Execute(w, r)
+}
+
+type htmlReport struct {
+	Title  string
+	Size   int
+	Parsed int
+	Err    string
+	Fields []*htmlField
+	Notes  []*htmlField
+	Rows   []htmlRow
+
+	all []*htmlField // indexed by ID
+}
+
+type htmlField struct {
+	ID         int
+	Path       string
+	Name       string
+	WireType   string
+	Kind       string
+	Value      string
+	Guess      string
+	Start      int
+	TagEnd     int
+	ValueStart int
+	End        int
+	Notes      []string
+	Children   []*htmlField
+}
+
+type htmlRow struct {
+	Offset string
+	Bytes  []htmlByte
+}
+
+type htmlByte struct {
+	Offset int
+	Hex    string
+	Char   string
+	Field  int
+	Role   string
+}
+
+// addFields converts nodes for the report, recording in owner which field each byte of the input belongs to.
+func (r *htmlReport) addFields(input []byte, nodes []*Node, path Path, owner []int) []*htmlField {
+	var fields []*htmlField
+	for _, n := range nodes {
+		p := append(path[:len(path):len(path)], n.Field)
+		tag := reader{buf: input[n.Offset:n.End]}
+		tag.decodeVarint()
+
+		f := &htmlField{
+			ID:         len(r.all),
+			Path:       p.String(),
+			Name:       n.Name,
+			WireType:   n.WireType.String(),
+			Kind:       n.Kind.String(),
+			Value:      formatValue(n),
+			Guess:      formatGuess(n),
+			Start:      n.Offset,
+			TagEnd:     n.Offset + tag.off,
+			ValueStart: n.ValueOffset,
+			End:        n.End,
+			Notes:      ambiguityNotes(n),
+		}
+		r.all = append(r.all, f)
+		if len(f.Notes) > 0 {
+			r.Notes = append(r.Notes, f)
+		}
+		for i := n.Offset; i < n.End; i++ {
+			owner[i] = f.ID
+		}
+		f.Children = r.addFields(input, n.Children, p, owner)
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// roleOf returns whether the byte at off is part of the tag, length prefix or value of the field with the given ID.
+func (r *htmlReport) roleOf(off, id int) string {
+	f := r.all[id]
+	switch {
+	case off < f.TagEnd:
+		return "tag"
+	case off < f.ValueStart:
+		return "length"
+	default:
+		return "value"
+	}
+}
+
+// ambiguityNotes describes why the interpretation of n is doubtful, if it is.
+func ambiguityNotes(n *Node) []string {
+	var notes []string
+	if n.Reason == "below-min-confidence" {
+		notes = append(notes, fmt.Sprintf("the best guess (%v, %.2f) was below the minimum confidence, so the raw value is shown", n.Candidates[0].Kind, n.Confidence))
+	}
+	for _, c := range n.Candidates {
+		if c.Kind == n.Kind || c.Score < n.Confidence-ambiguityMargin {
+			continue
+		}
+		note := fmt.Sprintf("could also be %v (%.2f %s)", c.Kind, c.Score, c.Reason)
+		if c.Value != nil {
+			note = fmt.Sprintf("could also be %v %s (%.2f %s)", c.Kind, FormatValue(c.Value), c.Score, c.Reason)
+		}
+		notes = append(notes, note)
+	}
+	return notes
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; color: #222; }
+.mono, .hex, .tree { font-family: monospace; font-size: 13px; }
+.panes { display: flex; gap: 2em; align-items: flex-start; }
+.tree ul { list-style: none; padding-left: 1.5em; margin: 0; }
+.tree > ul { padding-left: 0; }
+.field { cursor: default; white-space: pre; }
+.field .path { color: #666; }
+.field .name { font-weight: bold; }
+.field .kind { color: #05a; }
+.field .guess { color: #888; }
+.field.ambiguous .kind { color: #c60; }
+.hex { white-space: pre; }
+.hex .off { color: #888; }
+.tag { background: #cef; }
+.length { background: #fe9; }
+.value { background: #cfc; }
+.unparsed { background: #f99; }
+.hl { outline: 2px solid #d00; }
+.notes li { margin-bottom: 0.3em; }
+.error { color: #c00; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Size}} bytes{{if .Err}}, <span class="error">unparsed from offset {{.Parsed}}: {{.Err}}</span>{{end}}</p>
+<div class="panes">
+<div class="tree">
+<ul>{{range .Fields}}{{template "field" .}}{{end}}</ul>
+</div>
+<div class="hex">{{range .Rows}}<span class="off">{{.Offset}}</span>  {{range .Bytes}}<span id="b{{.Offset}}" class="{{.Role}}" data-f="{{.Field}}">{{.Hex}}</span> {{end}} {{range .Bytes}}<span class="{{.Role}}" data-f="{{.Field}}">{{.Char}}</span>{{end}}
+{{end}}</div>
+</div>
+{{if .Notes}}<h2>Ambiguities</h2>
+<ul class="notes">{{range .Notes}}<li><span class="mono">{{.Path}}{{if .Name}} {{.Name}}{{end}}</span> read as {{.Kind}} {{.Value}}<ul>{{range .Notes}}<li>{{.}}</li>{{end}}</ul></li>{{end}}</ul>{{end}}
+<script>
+(function() {
+	var fields = document.querySelectorAll(".field");
+	function highlight(id, on) {
+		var f = fields[id];
+		if (!f) {
+			return;
+		}
+		f.classList.toggle("hl", on);
+		for (var i = +f.dataset.start; i < +f.dataset.end; i++) {
+			document.getElementById("b" + i).classList.toggle("hl", on);
+		}
+	}
+	fields.forEach(function(f) {
+		f.addEventListener("mouseenter", function() { highlight(f.dataset.id, true); });
+		f.addEventListener("mouseleave", function() { highlight(f.dataset.id, false); });
+	});
+	document.querySelectorAll(".hex [data-f]").forEach(function(b) {
+		b.addEventListener("mouseenter", function() { highlight(b.dataset.f, true); });
+		b.addEventListener("mouseleave", function() { highlight(b.dataset.f, false); });
+	});
+})();
+</script>
+</body>
+</html>
+{{define "field"}}<li><div class="field{{if .Notes}} ambiguous{{end}}" data-id="{{.ID}}" data-start="{{.Start}}" data-end="{{.End}}"><span class="path">{{.Path}}</span>{{if .Name}} <span class="name">{{.Name}}</span>{{end}} <span class="kind">{{.Kind}}</span> {{.Value}} <span class="guess">({{.Guess}})</span></div>{{if .Children}}<ul>{{range .Children}}{{template "field" .}}{{end}}</ul>{{end}}</li>{{end}}
+`))
@@ -0,0 +1,47 @@
+package protoid
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWriteHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	var inner []byte
+	inner = protowire.AppendTag(inner, 1, protowire.BytesType)
+	inner = protowire.AppendString(inner, "<b>hello</b>")
+	var ser []byte
+	ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+	ser = protowire.AppendBytes(ser, inner)
+	ser = protowire.AppendTag(ser, 2, protowire.VarintType)
+	ser = protowire.AppendVarint(ser, 1)
+	ser = append(ser, 0x0a, 0x05)
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, DecodeTree(ser, Options{}), HTMLOptions{Title: "incident 42"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	assert.Contains(out, "<title>incident 42</title>")
+	assert.Contains(out, `<span class="path">1.1</span> <span class="kind">string</span> &#34;&lt;b&gt;hello&lt;/b&gt;&#34;`)
+	assert.NotContains(out, "<b>hello")
+	assert.Contains(out, `data-id="1" data-start="2" data-end="16"`)
+	assert.Contains(out, `<span id="b2" class="tag" data-f="1">0a</span>`)
+	assert.Contains(out, `class="unparsed"`)
+	assert.Contains(out, "unexpected end of input")
+
+	// 1 is only slightly more likely to be an integer than a bool
+	assert.Contains(out, "<h2>Ambiguities</h2>")
+	assert.Contains(out, "could also be bool true (0.40 zero-or-one)")
+
+	// everything is inline
+	assert.NotContains(out, "src=")
+	assert.NotContains(out, "href=")
+	assert.True(strings.HasPrefix(out, "<!DOCTYPE html>"))
+}
@@ -0,0 +1,155 @@
+package protoid
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// TypeMatch is a message type that a message might be, as found by Identify.  Score is in the range [0, 1], with 1 meaning that every
+// field of the message, recursively, fits the type.
+type TypeMatch struct {
+	Type  protoreflect.MessageDescriptor
+	Score float64
+}
+
+// Identify scores every message type in files by how well msg fits it, returning them best first.  A field fits if the type declares
+// it with a compatible wire type, and its value is in range; embedded messages are scored recursively.  Declared types that any value
+// fits, such as bytes and packed varints, count for a little less than a matching message or string.  Types that declare more of the
+// fields that appear score slightly higher, so that the most specific type wins when several fit.  Map entry types are skipped.
+func Identify(msg []byte, files *protoregistry.Files) ([]TypeMatch, error) {
+	if _, err := scanFields(msg, nil); err != nil {
+		return nil, err
+	}
+
+	var matches []TypeMatch
+	var add func(mds protoreflect.MessageDescriptors)
+	add = func(mds protoreflect.MessageDescriptors) {
+		for i := 0; i < mds.Len(); i++ {
+			md := mds.Get(i)
+			if !md.IsMapEntry() {
+				matches = append(matches, TypeMatch{Type: md, Score: typeScore(msg, md)})
+			}
+			add(md.Messages())
+		}
+	}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		add(fd.Messages())
+		return true
+	})
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Type.FullName() < matches[j].Type.FullName()
+	})
+	return matches, nil
+}
+
+// typeScore combines how well the fields of data fit md with how many of md's fields appear.
+func typeScore(data []byte, md protoreflect.MessageDescriptor) float64 {
+	fit, coverage := messageFit(data, md)
+	return 0.9*fit + 0.1*coverage
+}
+
+// messageFit returns the average fit of the fields of data to md, and the fraction of md's fields that appear.  Malformed data doesn't
+// fit at all.
+func messageFit(data []byte, md protoreflect.MessageDescriptor) (fit, coverage float64) {
+	var total float64
+	var count int
+	seen := make(map[protoreflect.FieldNumber]bool)
+	it := NewIterator(data)
+	for it.Next() {
+		f := it.Field()
+		count++
+		fd := md.Fields().ByNumber(protoreflect.FieldNumber(f.Number))
+		if fd == nil {
+			continue
+		}
+		seen[fd.Number()] = true
+		total += fieldFit(f, fd)
+	}
+	if it.Err() != nil {
+		return 0, 0
+	}
+	if n := md.Fields().Len(); n > 0 {
+		coverage = float64(len(seen)) / float64(n)
+	}
+	if count == 0 {
+		// any type fits an empty message
+		return 1, coverage
+	}
+	return total / float64(count), coverage
+}
+
+// fieldFit returns how well a single field fits its declaration, from 0 to 1.
+func fieldFit(f Field, fd protoreflect.FieldDescriptor) float64 {
+	expected := kindWireType(fd.Kind())
+	if f.WireType != expected {
+		if f.WireType == WireBytes && fd.IsList() && expected != WireBytes {
+			return packedFit(f.Value, fd, expected)
+		}
+		return 0
+	}
+
+	switch f.WireType {
+	case WireVarint:
+		r := reader{buf: f.Value}
+		if !varintFits(r.decodeVarint(), fd) {
+			return 0.5
+		}
+	case WireBytes:
+		switch fd.Kind() {
+		case protoreflect.StringKind:
+			if !utf8.Valid(f.Value) && validatesUTF8(fd) {
+				return 0.5
+			}
+			if len(f.Value) > 0 {
+				// text with control characters is probably something else
+				score, _ := stringScore(f.Value)
+				return 0.7 + 0.3*score/0.85
+			}
+		case protoreflect.BytesKind:
+			// anything fits bytes, so that is weaker evidence than a
+			// matching embedded message or string
+			return 0.9
+		case protoreflect.MessageKind:
+			fit, _ := messageFit(f.Value, fd.Message())
+			return 0.5 + 0.5*fit
+		}
+	}
+	return 1
+}
+
+// packedFit returns how well a packed field fits its declaration.
+func packedFit(data []byte, fd protoreflect.FieldDescriptor, wiretype WireType) float64 {
+	r := reader{buf: data}
+	fits := true
+	for !r.done() {
+		switch wiretype {
+		case WireVarint:
+			if v := r.decodeVarint(); r.err == nil && !varintFits(v, fd) {
+				fits = false
+			}
+		case WireFixed64:
+			r.readLeUint64()
+		case WireFixed32:
+			r.readLeUint32()
+		}
+	}
+	switch {
+	case r.err != nil:
+		return 0
+	case !fits:
+		return 0.5
+	case wiretype == WireVarint:
+		// almost any data can be read as packed varints, so that is weaker
+		// evidence than a matching embedded message
+		return 0.8
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,63 @@
+package protoid
+
+import (
+	"testing"
+
+	protov1 "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func testFiles(t *testing.T) *protoregistry.Files {
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(descriptorOf(&SingleString{}).ParentFile()),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestIdentify(t *testing.T) {
+	assert := assert.New(t)
+	files := testFiles(t)
+
+	identify := func(m protov1.Message) []TypeMatch {
+		ser, err := protov1.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches, err := Identify(ser, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return matches
+	}
+
+	matches := identify(&TwoStrings{String_1: "a", String_2: "b"})
+	assert.Len(matches, 13)
+	assert.Equal("protoid.TwoStrings", string(matches[0].Type.FullName()))
+	assert.Equal(1.0, matches[0].Score)
+	assert.Less(matches[1].Score, 1.0)
+
+	matches = identify(&SingleFixed64{TheFixed64: 7})
+	assert.Equal("protoid.SingleFixed64", string(matches[0].Type.FullName()))
+
+	// a repeated field with one element looks just like a singular one
+	matches = identify(&SingleEmbedded{MySingleString: &SingleString{TheString: "123"}})
+	assert.Equal("protoid.RepeatedEmbedded", string(matches[0].Type.FullName()))
+	assert.Equal("protoid.SingleEmbedded", string(matches[1].Type.FullName()))
+	assert.Equal(matches[0].Score, matches[1].Score)
+
+	// proto2 doesn't validate UTF-8, so invalid UTF-8 fits its strings better
+	latin1 := []byte{0x0a, 0x03, 'J', 'o', 0xe9}
+	fit2, _ := messageFit(latin1, proto2Message(t))
+	fit3, _ := messageFit(latin1, descriptorOf(&SingleString{}))
+	assert.Equal(0.5, fit3)
+	assert.Greater(fit2, fit3)
+
+	_, err := Identify([]byte{0x0a}, files)
+	assert.Error(err)
+}
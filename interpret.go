@@ -0,0 +1,205 @@
+package protoid
+
+import (
+	"fmt"
+	"math"
+)
+
+// Kind identifies one possible interpretation of a raw value read from the wire.
+type Kind int
+
+const (
+	// KindUnsigned is an unsigned integer, e.g. uint64 or fixed32.
+	KindUnsigned Kind = iota
+	// KindSigned is a two's complement signed integer, e.g. int64 or sfixed32.
+	KindSigned
+	// KindFloat is an IEEE-754 float or double.
+	KindFloat
+	// KindZigzag is a zigzag encoded signed integer, i.e. sint32 or sint64.
+	KindZigzag
+	// KindBool is a boolean.
+	KindBool
+	// KindMessage is an embedded message.
+	KindMessage
+	// KindString is a UTF-8 string.
+	KindString
+	// KindBytes is an opaque sequence of bytes.
+	KindBytes
+	// KindPacked is a packed repeated field of varint or fixed width values.
+	KindPacked
+)
+
+var kindNames = map[Kind]string{
+	KindUnsigned: "unsigned",
+	KindSigned:   "signed",
+	KindFloat:    "float",
+	KindZigzag:   "zigzag",
+	KindBool:     "bool",
+	KindMessage:  "message",
+	KindString:   "string",
+	KindBytes:    "bytes",
+	KindPacked:   "packed",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the kind as its name, so that it is readable in JSON.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes the name of a kind.
+func (k *Kind) UnmarshalText(text []byte) error {
+	for kind, name := range kindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown kind %q", text)
+}
+
+// Candidate is a single interpretation of a raw value along with a score in the range [0, 1] indicating how plausible protoid thinks it is.
+// Reason is a short machine readable explanation of the score, such as "printable-utf8" or "negative-int32".
+type Candidate struct {
+	Kind   Kind
+	Value  interface{}
+	Score  float64
+	Reason string
+}
+
+// InterpretVarint returns the possible interpretations of a varint value (int32, int64, uint32, uint64, sint32, sint64, bool or enum), most plausible first.
+func InterpretVarint(raw uint64) []Candidate {
+	return varintCandidates(raw, nil)
+}
+
+// varintCandidates is InterpretVarint, appending the candidates to buf.
+func varintCandidates(raw uint64, buf []Candidate) []Candidate {
+	cands := append(buf[:0], Candidate{Kind: KindUnsigned, Value: raw, Score: 0.5, Reason: "varint"})
+
+	if raw&(1<<63) != 0 {
+		// Only a negative int32 or int64 needs all 10 bytes of a varint.
+		// Negative int32 values are sign extended to 64 bits, so anything in
+		// the int32 range is particularly likely.
+		cands[0].Score, cands[0].Reason = 0.1, "huge-unsigned"
+		s := int64(raw)
+		score, reason := 0.7, "negative-int64"
+		if s >= math.MinInt32 {
+			score, reason = 0.9, "negative-int32"
+		}
+		cands = append(cands, Candidate{Kind: KindSigned, Value: s, Score: score, Reason: reason})
+	}
+
+	// Any varint is a valid zigzag value, so there is nothing in the data
+	// to suggest it. It is only ever offered as an alternative.
+	z := int64(raw>>1) ^ -int64(raw&1)
+	cands = append(cands, Candidate{Kind: KindZigzag, Value: z, Score: 0.2, Reason: "zigzag-possible"})
+
+	if raw <= 1 {
+		cands = append(cands, Candidate{Kind: KindBool, Value: raw == 1, Score: 0.4, Reason: "zero-or-one"})
+	}
+
+	sortCandidates(cands)
+	return cands
+}
+
+// InterpretFixed64 returns the possible interpretations of a 64 bit fixed width value (fixed64, sfixed64 or double), most plausible first.
+func InterpretFixed64(raw uint64) []Candidate {
+	return fixed64Candidates(raw, nil)
+}
+
+// fixed64Candidates is InterpretFixed64, appending the candidates to buf.
+func fixed64Candidates(raw uint64, buf []Candidate) []Candidate {
+	cands := append(buf[:0], Candidate{Kind: KindUnsigned, Value: raw, Score: 0.5, Reason: "fixed-width"})
+
+	if raw&(1<<63) != 0 {
+		// An unsigned value this large is unusual. A negative number is
+		// more likely, as long as it isn't implausibly large itself.
+		cands[0].Score, cands[0].Reason = 0.1, "huge-unsigned"
+		s := int64(raw)
+		score, reason := 0.1, "huge-negative"
+		if s > -(1 << 53) {
+			score, reason = 0.6, "negative-fixed"
+		}
+		cands = append(cands, Candidate{Kind: KindSigned, Value: s, Score: score, Reason: reason})
+	}
+
+	if raw != 0 {
+		f := math.Float64frombits(raw)
+		score, reason := floatScore(f, 1e-9, 1e15, 52, raw&(1<<52-1))
+		cands = append(cands, Candidate{Kind: KindFloat, Value: f, Score: score, Reason: reason})
+	}
+
+	sortCandidates(cands)
+	return cands
+}
+
+// InterpretFixed32 returns the possible interpretations of a 32 bit fixed width value (fixed32, sfixed32 or float), most plausible first.
+func InterpretFixed32(raw uint32) []Candidate {
+	return fixed32Candidates(raw, nil)
+}
+
+// fixed32Candidates is InterpretFixed32, appending the candidates to buf.
+func fixed32Candidates(raw uint32, buf []Candidate) []Candidate {
+	cands := append(buf[:0], Candidate{Kind: KindUnsigned, Value: raw, Score: 0.5, Reason: "fixed-width"})
+
+	if raw&(1<<31) != 0 {
+		cands[0].Score, cands[0].Reason = 0.1, "huge-unsigned"
+		s := int32(raw)
+		score, reason := 0.1, "huge-negative"
+		if s > -(1 << 24) {
+			score, reason = 0.6, "negative-fixed"
+		}
+		cands = append(cands, Candidate{Kind: KindSigned, Value: s, Score: score, Reason: reason})
+	}
+
+	if raw != 0 {
+		f := math.Float32frombits(raw)
+		score, reason := floatScore(float64(f), 1e-6, 1e9, 23, uint64(raw&(1<<23-1)))
+		cands = append(cands, Candidate{Kind: KindFloat, Value: f, Score: score, Reason: reason})
+	}
+
+	sortCandidates(cands)
+	return cands
+}
+
+// floatScore rates how likely it is that f was written as a floating point number rather than an integer that happens to share its bit pattern.
+// Values outside of [min, max] in magnitude are considered unlikely, as are values that need every bit of their mantissa, since real world
+// numbers like 0.5 or 1234.25 tend to leave the low mantissa bits clear.
+func floatScore(f float64, min, max float64, mantissaBits uint, mantissa uint64) (float64, string) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, "not-finite"
+	}
+	abs := math.Abs(f)
+	if abs < min || abs > max {
+		return 0.05, "implausible-float"
+	}
+	if mantissa == 0 || trailingZeros(mantissa) >= mantissaBits/2 {
+		return 0.8, "round-float"
+	}
+	return 0.7, "plausible-float"
+}
+
+func trailingZeros(v uint64) uint {
+	var n uint
+	for v != 0 && v&1 == 0 {
+		v >>= 1
+		n++
+	}
+	return n
+}
+
+// sortCandidates orders cands by descending score, keeping the original order for equal scores.  There are only ever a handful of
+// candidates, so an insertion sort is quickest.
+func sortCandidates(cands []Candidate) {
+	for i := 1; i < len(cands); i++ {
+		for j := i; j > 0 && cands[j].Score > cands[j-1].Score; j-- {
+			cands[j], cands[j-1] = cands[j-1], cands[j]
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package protoid
+
+import (
+	"math"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestInterpretFixed64(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		raw      uint64
+		kind     Kind
+		expected interface{}
+	}{
+		{12345678, KindUnsigned, uint64(12345678)},
+		{1700000000000, KindUnsigned, uint64(1700000000000)},
+		{0, KindUnsigned, uint64(0)},
+		{math.Float64bits(3.14159), KindFloat, 3.14159},
+		{math.Float64bits(-273.15), KindFloat, -273.15},
+		{math.Float64bits(0.5), KindFloat, 0.5},
+		{uint64(1<<64 - 5), KindSigned, int64(-5)},
+	}
+
+	for _, tt := range tests {
+		cands := InterpretFixed64(tt.raw)
+		assert.Equal(tt.kind, cands[0].Kind, "raw %x", tt.raw)
+		assert.Equal(tt.expected, cands[0].Value, "raw %x", tt.raw)
+	}
+}
+
+func TestInterpretFixed32(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		raw      uint32
+		kind     Kind
+		expected interface{}
+	}{
+		{12345678, KindUnsigned, uint32(12345678)},
+		{80, KindUnsigned, uint32(80)},
+		{math.Float32bits(1.5), KindFloat, float32(1.5)},
+		{math.Float32bits(-0.25), KindFloat, float32(-0.25)},
+		{uint32(1<<32 - 100), KindSigned, int32(-100)},
+	}
+
+	for _, tt := range tests {
+		cands := InterpretFixed32(tt.raw)
+		assert.Equal(tt.kind, cands[0].Kind, "raw %x", tt.raw)
+		assert.Equal(tt.expected, cands[0].Value, "raw %x", tt.raw)
+	}
+}
+
+func TestInterpretKeepsAlternatives(t *testing.T) {
+	assert := assert.New(t)
+
+	raw := math.Float64bits(2.5)
+	cands := InterpretFixed64(raw)
+
+	assert.Equal(KindFloat, cands[0].Kind)
+	var found bool
+	for _, c := range cands {
+		if c.Kind == KindUnsigned {
+			found = true
+			assert.Equal(raw, c.Value)
+		}
+	}
+	assert.True(found, "unsigned interpretation missing")
+}
+
+func TestDouble(t *testing.T) {
+	assert := assert.New(t)
+
+	var ser []byte
+	ser = protowire.AppendTag(ser, 1, protowire.Fixed64Type)
+	ser = protowire.AppendFixed64(ser, math.Float64bits(51.5074))
+	ser = protowire.AppendTag(ser, 2, protowire.Fixed32Type)
+	ser = protowire.AppendFixed32(ser, math.Float32bits(-0.1278))
+
+	expected := map[int]interface{}{1: 51.5074, 2: float32(-0.1278)}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
+
+func TestInterpretVarint(t *testing.T) {
+	assert := assert.New(t)
+
+	tests := []struct {
+		raw      uint64
+		kind     Kind
+		expected interface{}
+	}{
+		{0, KindUnsigned, uint64(0)},
+		{1, KindUnsigned, uint64(1)},
+		{123456, KindUnsigned, uint64(123456)},
+		{uint64(1<<64 - 1), KindSigned, int64(-1)},
+		{uint64(1<<64 - 1<<40), KindSigned, int64(-1 << 40)},
+	}
+
+	for _, tt := range tests {
+		cands := InterpretVarint(tt.raw)
+		assert.Equal(tt.kind, cands[0].Kind, "raw %x", tt.raw)
+		assert.Equal(tt.expected, cands[0].Value, "raw %x", tt.raw)
+	}
+}
+
+func TestInterpretVarintAlternatives(t *testing.T) {
+	assert := assert.New(t)
+
+	values := map[Kind]interface{}{}
+	for _, c := range InterpretVarint(1) {
+		values[c.Kind] = c.Value
+	}
+
+	assert.Equal(map[Kind]interface{}{
+		KindUnsigned: uint64(1),
+		KindZigzag:   int64(-1),
+		KindBool:     true,
+	}, values)
+}
+
+func TestNegativeInt32(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &SingleInt32{TheInt32: -42}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int]interface{}{1: int64(-42)}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
@@ -0,0 +1,102 @@
+package protoid
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Field is a single top-level field of a message, as found by an Iterator.
+type Field struct {
+	Number   int
+	WireType WireType
+	// Value is the encoded value: the bytes of the varint or fixed width value, or the contents of a length-delimited value.  It refers
+	// to the buffer being iterated over.
+	Value []byte
+}
+
+// Iterator scans the top-level fields of a message without interpreting their values.  It never allocates, so it is suitable for
+// filtering large numbers of messages on a few fields.
+//
+//	it := protoid.NewIterator(buf)
+//	for it.Next() {
+//		f := it.Field()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	r     reader
+	field Field
+}
+
+// NewIterator returns an Iterator over the fields of buf.
+func NewIterator(buf []byte) Iterator {
+	return Iterator{r: reader{buf: buf}}
+}
+
+// Next advances to the next field, returning false at the end of the input or if an error occurs.
+func (it *Iterator) Next() bool {
+	if it.r.done() {
+		return false
+	}
+
+	val := it.r.decodeVarint()
+	if it.r.err != nil {
+		return false
+	}
+	it.field.Number = int(val >> 3)
+	it.field.WireType = WireType(val & 0x07)
+
+	start := it.r.buf
+	switch it.field.WireType {
+	case WireVarint:
+		it.r.decodeVarint()
+		it.field.Value = start[:len(start)-len(it.r.buf)]
+	case WireFixed64:
+		it.r.readLeUint64()
+		it.field.Value = start[:len(start)-len(it.r.buf)]
+	case WireBytes:
+		it.field.Value = it.r.readLenDelimValue()
+	case WireStartGroup, WireEndGroup:
+		it.r.err = ErrNotImplemented
+	case WireFixed32:
+		it.r.readLeUint32()
+		it.field.Value = start[:len(start)-len(it.r.buf)]
+	default:
+		it.r.err = fmt.Errorf("unsupported wire type : %d", it.field.WireType)
+	}
+	return it.r.err == nil
+}
+
+// Field returns the current field.
+func (it *Iterator) Field() Field {
+	return it.field
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.r.err
+}
+
+// Fields returns an iterator over the top-level fields of buf.  If the input is malformed the final pair holds the error.
+//
+//	for f, err := range protoid.Fields(buf) {
+//		if err != nil {
+//			...
+//		}
+//		...
+//	}
+func Fields(buf []byte) iter.Seq2[Field, error] {
+	return func(yield func(Field, error) bool) {
+		it := NewIterator(buf)
+		for it.Next() {
+			if !yield(it.field, nil) {
+				return
+			}
+		}
+		if it.r.err != nil {
+			yield(Field{}, it.r.err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func iteratorTestMessage() []byte {
+	var ser []byte
+	ser = protowire.AppendTag(ser, 1, protowire.VarintType)
+	ser = protowire.AppendVarint(ser, 150)
+	ser = protowire.AppendTag(ser, 2, protowire.BytesType)
+	ser = protowire.AppendString(ser, "hello")
+	ser = protowire.AppendTag(ser, 3, protowire.Fixed32Type)
+	ser = protowire.AppendFixed32(ser, 7)
+	ser = protowire.AppendTag(ser, 4, protowire.Fixed64Type)
+	ser = protowire.AppendFixed64(ser, 8)
+	return ser
+}
+
+func TestIterator(t *testing.T) {
+	assert := assert.New(t)
+
+	var fields []Field
+	it := NewIterator(iteratorTestMessage())
+	for it.Next() {
+		fields = append(fields, it.Field())
+	}
+	assert.NoError(it.Err())
+
+	assert.Equal([]Field{
+		{Number: 1, WireType: WireVarint, Value: []byte{0x96, 0x01}},
+		{Number: 2, WireType: WireBytes, Value: []byte("hello")},
+		{Number: 3, WireType: WireFixed32, Value: []byte{7, 0, 0, 0}},
+		{Number: 4, WireType: WireFixed64, Value: []byte{8, 0, 0, 0, 0, 0, 0, 0}},
+	}, fields)
+}
+
+func TestIteratorTruncated(t *testing.T) {
+	assert := assert.New(t)
+
+	it := NewIterator([]byte{0x08, 0x96, 0x01, 0x12, 0x05, 'h'})
+	assert.True(it.Next())
+	assert.False(it.Next())
+	assert.Equal(ErrUnexpectedEndOfInput, it.Err())
+}
+
+func TestFields(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &TwoStrings{String_1: "string1", String_2: "string2"}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	for f, err := range Fields(ser) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, string(f.Value))
+	}
+	assert.Equal([]string{"string1", "string2"}, values)
+
+	var errs []error
+	for _, err := range Fields([]byte{0x0b}) {
+		errs = append(errs, err)
+	}
+	assert.Equal([]error{ErrNotImplemented}, errs)
+}
+
+func TestIteratorAllocs(t *testing.T) {
+	ser := iteratorTestMessage()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		it := NewIterator(ser)
+		for it.Next() {
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		for _, err := range Fields(ser) {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkIterator(b *testing.B) {
+	ser := iteratorTestMessage()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(ser)))
+	for i := 0; i < b.N; i++ {
+		it := NewIterator(ser)
+		for it.Next() {
+		}
+	}
+}
+
+func BenchmarkFields(b *testing.B) {
+	ser := iteratorTestMessage()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(ser)))
+	for i := 0; i < b.N; i++ {
+		for range Fields(ser) {
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	ser := iteratorTestMessage()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(ser)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(ser); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package protoid
+
+import (
+	"math"
+	"reflect"
+)
+
+// Map fields are encoded on the wire as a repeated embedded message, one per
+// entry, with the key at field 1 and the value at field 2. Decode would
+// otherwise present these as a []interface{} of two field maps, so once a
+// message has been decoded we look for repeated values with exactly that shape
+// and turn them back into a Go map.
+//
+// A map with a single entry is indistinguishable from an ordinary embedded
+// message with fields 1 and 2, so at least two entries are required.
+
+// detectMaps replaces any repeated map entry values in m with a
+// map[interface{}]interface{} keyed by the entry keys.
+func detectMaps(m map[int]interface{}) {
+	for k, v := range m {
+		slice, ok := v.([]interface{})
+		if !ok {
+			continue
+		}
+		if mv, ok := asMap(slice); ok {
+			m[k] = mv
+		}
+	}
+}
+
+// asMap returns the entries of slice as a map, if every element is a map
+// entry with a scalar key of a consistent type and the keys are distinct.
+func asMap(slice []interface{}) (map[interface{}]interface{}, bool) {
+	if len(slice) < 2 {
+		return nil, false
+	}
+
+	var keys []interface{}
+	var value interface{}
+	missingKeys := 0
+	for _, elem := range slice {
+		entry, ok := elem.(map[int]interface{})
+		if !ok || !isMapEntry(entry) {
+			return nil, false
+		}
+		if v, ok := entry[2]; ok && value == nil {
+			value = v
+		}
+		key, ok := entry[1]
+		if !ok {
+			// proto3 omits a default key, but keys are unique so only one
+			// entry can do so.
+			missingKeys++
+			if missingKeys > 1 {
+				return nil, false
+			}
+			continue
+		}
+		keys = append(keys, key)
+	}
+	keyType, ok := mapKeyType(keys)
+	if !ok || value == nil {
+		// Without a value, the entries can't be told from a repeated
+		// message with a single field.
+		return nil, false
+	}
+
+	out := make(map[interface{}]interface{}, len(slice))
+	for _, elem := range slice {
+		entry := elem.(map[int]interface{})
+		key, ok := entry[1]
+		if !ok {
+			key = keyType
+		}
+		key = convertKey(key, keyType)
+		if _, dup := out[key]; dup {
+			return nil, false
+		}
+		v, ok := entry[2]
+		if !ok {
+			// proto3 omits a default value too, which has the type of the
+			// values that were written.
+			v = zeroValue(value)
+		}
+		out[key] = v
+	}
+	return out, true
+}
+
+// isMapEntry reports whether entry has the shape of a map entry message: a
+// key at field 1 and a value at field 2, either of which may be missing if it
+// has its default value, and nothing else.
+func isMapEntry(entry map[int]interface{}) bool {
+	if len(entry) == 0 {
+		return false
+	}
+	for k := range entry {
+		if k != 1 && k != 2 {
+			return false
+		}
+	}
+	return true
+}
+
+// zeroValue returns the zero value of the type of v, as it would have been
+// decoded.
+func zeroValue(v interface{}) interface{} {
+	switch v.(type) {
+	case map[int]interface{}:
+		return map[int]interface{}{}
+	case []byte:
+		return []byte{}
+	default:
+		return reflect.Zero(reflect.TypeOf(v)).Interface()
+	}
+}
+
+// mapKeyType returns the zero value of the type that keys should all be
+// converted to, if they can be the keys of one map.  Decode can read the keys
+// of one map as different types, such as a negative int64 key as int64 and
+// the rest as uint64, or a fixed32 key whose bits happen to look like a float
+// as float32, so keys of the same width but different types are converted to
+// the signed integer type of that width.  Map keys can't be floats, so keys
+// that all read as floats aren't accepted.
+func mapKeyType(keys []interface{}) (interface{}, bool) {
+	var keyType interface{}
+	mixed := false
+	for _, key := range keys {
+		zero, ok := zeroKey(key)
+		switch {
+		case !ok:
+			return nil, false
+		case keyType == nil, keyType == zero:
+			keyType = zero
+		case keyWidth(keyType) != 0 && keyWidth(keyType) == keyWidth(zero):
+			mixed = true
+		default:
+			return nil, false
+		}
+	}
+	switch {
+	case keyType == nil:
+		return nil, false
+	case mixed && keyWidth(keyType) == 64:
+		return int64(0), true
+	case mixed:
+		return int32(0), true
+	case keyType == float64(0) || keyType == float32(0):
+		return nil, false
+	default:
+		return keyType, true
+	}
+}
+
+// zeroKey returns the zero value of the type of key, if key has a type that
+// is allowed as a map key, or may be one once converted by convertKey.
+func zeroKey(key interface{}) (interface{}, bool) {
+	switch key.(type) {
+	case string:
+		return "", true
+	case uint64:
+		return uint64(0), true
+	case int64:
+		return int64(0), true
+	case float64:
+		return float64(0), true
+	case uint32:
+		return uint32(0), true
+	case int32:
+		return int32(0), true
+	case float32:
+		return float32(0), true
+	default:
+		return nil, false
+	}
+}
+
+// keyWidth returns the size in bits of a numeric key type, or 0 for a string.
+func keyWidth(zero interface{}) int {
+	switch zero.(type) {
+	case uint64, int64, float64:
+		return 64
+	case uint32, int32, float32:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// convertKey converts key to the type of keyType, as chosen by mapKeyType.
+func convertKey(key, keyType interface{}) interface{} {
+	switch keyType.(type) {
+	case int64:
+		switch k := key.(type) {
+		case uint64:
+			return int64(k)
+		case float64:
+			return int64(math.Float64bits(k))
+		}
+	case int32:
+		switch k := key.(type) {
+		case uint32:
+			return int32(k)
+		case float32:
+			return int32(math.Float32bits(k))
+		}
+	}
+	return key
+}
@@ -0,0 +1,290 @@
+package protoid
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// WireType is the type of encoding used for a field on the wire.
+type WireType int
+
+const (
+	// WireVarint is used for int32, int64, uint32, uint64, sint32, sint64, bool and enum.
+	WireVarint WireType = 0
+	// WireFixed64 is used for fixed64, sfixed64 and double.
+	WireFixed64 WireType = 1
+	// WireBytes is used for strings, bytes, embedded messages and packed repeated fields.
+	WireBytes WireType = 2
+	// WireStartGroup starts a (deprecated) group.
+	WireStartGroup WireType = 3
+	// WireEndGroup ends a (deprecated) group.
+	WireEndGroup WireType = 4
+	// WireFixed32 is used for fixed32, sfixed32 and float.
+	WireFixed32 WireType = 5
+)
+
+var wireTypeNames = map[WireType]string{
+	WireVarint:     "varint",
+	WireFixed64:    "fixed64",
+	WireBytes:      "length-delimited",
+	WireStartGroup: "start-group",
+	WireEndGroup:   "end-group",
+	WireFixed32:    "fixed32",
+}
+
+func (wt WireType) String() string {
+	if name, ok := wireTypeNames[wt]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the wire type as its name, so that it is readable in JSON.
+func (wt WireType) MarshalText() ([]byte, error) {
+	return []byte(wt.String()), nil
+}
+
+// UnmarshalText decodes the name of a wire type.
+func (wt *WireType) UnmarshalText(text []byte) error {
+	for t, name := range wireTypeNames {
+		if name == string(text) {
+			*wt = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown wire type %q", text)
+}
+
+// Options controls how DecodeNodes, Decoder and DecodeBatch interpret messages.
+type Options struct {
+	// MinConfidence is the lowest score for which a guess is used. A field whose best guess scores lower is returned as its raw wire
+	// value instead: bytes for a length-delimited field, or an unsigned integer otherwise.  Its Confidence is still that of the rejected
+	// guess.
+	MinConfidence float64
+	// Type is the message type of the messages being decoded, if it is known.  Fields that it declares are decoded as their declared
+	// types and named, with a Confidence of 1, leaving only the fields it doesn't know about to be guessed.  Only the functions that
+	// return Nodes use it: DecodeNodes, DecodeValue, DecodeTree, DecodeBatch and the Decoder methods other than Decode.  Decoder.Decode,
+	// like the package level Decode, ignores it.
+	Type protoreflect.MessageDescriptor
+}
+
+// Node is a single decoded field.  Kind and Value hold protoid's best guess for the field, with Confidence being its score and Reason the
+// explanation for it.  Candidates holds every interpretation that was considered, most plausible first.  For embedded messages Value is
+// nil and the fields of the message are in Children.  The alternatives for an embedded message don't have their values filled in, as
+// copying every level of a deeply nested message would be expensive.
+type Node struct {
+	Field int
+	// Name is the name of the field in Options.Type, or empty if the type isn't known or doesn't declare it.
+	Name     string
+	WireType WireType
+	// Offset is where the field's tag starts in the input, ValueOffset where its value starts after any length prefix, and End is
+	// just after its last byte.
+	Offset      int
+	ValueOffset int
+	End         int
+
+	Kind       Kind
+	Value      interface{}
+	Confidence float64
+	Reason     string
+	Candidates []Candidate
+	Children   []*Node
+}
+
+// DecodeNodes decodes an arbitrary protocol buffers message into a list of its fields, in the order they appear in the input.  Unlike
+// Decode, every field carries a confidence score so that callers can tell a near certain guess from a coin toss.
+func DecodeNodes(input []byte, opts Options) ([]*Node, error) {
+	var res Result
+	na := &nodeValueApplier{opts: opts}
+	if err := na.decode(context.Background(), input, &res, &walker{}); err != nil {
+		return nil, err
+	}
+	return res.Fields, nil
+}
+
+// DecodeValue decodes the value of the length-delimited field n of input as an embedded message, whatever protoid guessed it to be.
+// The offsets of the nodes returned are relative to the start of input, as if they had been decoded along with n.
+func DecodeValue(input []byte, n *Node, opts Options) ([]*Node, error) {
+	nodes, err := DecodeNodes(input[n.ValueOffset:n.End], opts)
+	if err != nil {
+		return nil, err
+	}
+	shiftNodes(nodes, n.ValueOffset)
+	return nodes, nil
+}
+
+// shiftNodes moves the offsets of nodes decoded from part of an input to be relative to the whole of it.
+func shiftNodes(nodes []*Node, base int) {
+	for _, n := range nodes {
+		n.Offset += base
+		n.ValueOffset += base
+		n.End += base
+		shiftNodes(n.Children, base)
+	}
+}
+
+// Result holds the fields decoded by Decoder.DecodeInto.  The memory it holds is reused by later calls, so the nodes in it are only
+// valid until it is next used or Reset.
+type Result struct {
+	// Fields holds the top-level fields of the message.
+	Fields []*Node
+
+	nodes []*Node // every node allocated so far, for reuse
+	used  int     // how many of nodes are in use
+}
+
+// Reset empties r, keeping its memory for reuse.
+func (r *Result) Reset() {
+	r.Fields = r.Fields[:0]
+	r.used = 0
+}
+
+// newNode returns an empty node, reusing a previously allocated one where possible.
+func (r *Result) newNode() *Node {
+	if r.used == len(r.nodes) {
+		r.nodes = append(r.nodes, &Node{})
+	}
+	n := r.nodes[r.used]
+	r.used++
+	*n = Node{Candidates: n.Candidates[:0], Children: n.Children[:0]}
+	return n
+}
+
+// nodeValueApplier builds the nodes returned by DecodeNodes.  It keeps a stack of the embedded message nodes being walked.
+type nodeValueApplier struct {
+	opts  Options
+	w     *walker
+	res   *Result
+	root  Node
+	stack []*Node
+}
+
+// decode walks input with w, adding its fields to res, and gives up if ctx is cancelled.
+func (na *nodeValueApplier) decode(ctx context.Context, input []byte, res *Result, w *walker) error {
+	res.Reset()
+	na.res = res
+	na.w = w
+	na.root.Children = res.Fields
+	na.stack = append(na.stack[:0], &na.root)
+	defer func() {
+		na.res, na.w, na.root.Children = nil, nil, nil
+		clear(na.stack[:cap(na.stack)])
+	}()
+
+	if err := w.walk(ctx, input, na); err != nil {
+		res.Reset()
+		return err
+	}
+	res.Fields = na.root.Children
+	if na.opts.Type != nil {
+		applySchema(input, res.Fields, na.opts.Type, na.opts)
+	}
+	return nil
+}
+
+func (na *nodeValueApplier) Varint(path Path, value uint64) error {
+	n := na.add(path, WireVarint, false)
+	n.Candidates = varintCandidates(value, n.Candidates)
+	na.choose(n)
+	return nil
+}
+
+func (na *nodeValueApplier) Fixed64(path Path, value uint64) error {
+	n := na.add(path, WireFixed64, false)
+	n.Candidates = fixed64Candidates(value, n.Candidates)
+	na.choose(n)
+	return nil
+}
+
+func (na *nodeValueApplier) Bytes(path Path, data []byte) error {
+	spans, err := scanFields(data, nil)
+	return na.classifiedBytes(path, data, bytesCandidates(data, spans, err == nil, nil))
+}
+
+func (na *nodeValueApplier) classifiedBytes(path Path, data []byte, cands []Candidate) error {
+	n := na.add(path, WireBytes, false)
+	n.Candidates = append(n.Candidates, cands...)
+	for i := range n.Candidates {
+		switch n.Candidates[i].Kind {
+		case KindString:
+			n.Candidates[i].Value = string(data)
+		case KindBytes:
+			n.Candidates[i].Value = copyBytes(data)
+		}
+	}
+	na.choose(n)
+	return nil
+}
+
+func (na *nodeValueApplier) Fixed32(path Path, value uint32) error {
+	n := na.add(path, WireFixed32, false)
+	n.Candidates = fixed32Candidates(value, n.Candidates)
+	na.choose(n)
+	return nil
+}
+
+func (na *nodeValueApplier) EnterMessage(path Path, data []byte) error {
+	spans, err := scanFields(data, nil)
+	return na.classifiedEnterMessage(path, data, bytesCandidates(data, spans, err == nil, nil))
+}
+
+func (na *nodeValueApplier) classifiedEnterMessage(path Path, data []byte, cands []Candidate) error {
+	if cands[0].Score < na.opts.MinConfidence {
+		// Bytes will fall back to the raw value.
+		return SkipMessage
+	}
+	n := na.add(path, WireBytes, true)
+	n.Candidates = append(n.Candidates, cands...)
+	na.choose(n)
+	na.stack = append(na.stack, n)
+	return nil
+}
+
+func (na *nodeValueApplier) LeaveMessage(path Path) error {
+	na.stack = na.stack[:len(na.stack)-1]
+	return nil
+}
+
+// add appends a new node to the current message.
+func (na *nodeValueApplier) add(path Path, wiretype WireType, message bool) *Node {
+	n := na.res.newNode()
+	n.Field = path[len(path)-1]
+	n.WireType = wiretype
+	n.Offset = na.w.field.off
+	n.ValueOffset = na.w.field.valueOff
+	n.End = na.w.field.end
+	if !message {
+		// only keep the memory of reused children for messages, so that
+		// other nodes look the same as freshly allocated ones.
+		n.Children = nil
+	}
+
+	parent := na.stack[len(na.stack)-1]
+	parent.Children = append(parent.Children, n)
+	return n
+}
+
+// choose sets the value of n to the best of its candidates, or to the raw wire value if that isn't good enough.
+func (na *nodeValueApplier) choose(n *Node) {
+	best := n.Candidates[0]
+	n.Kind = best.Kind
+	n.Value = best.Value
+	n.Confidence = best.Score
+	n.Reason = best.Reason
+
+	if best.Score < na.opts.MinConfidence {
+		raw := KindUnsigned
+		if n.WireType == WireBytes {
+			raw = KindBytes
+		}
+		for _, c := range n.Candidates {
+			if c.Kind == raw {
+				n.Kind = c.Kind
+				n.Value = c.Value
+			}
+		}
+		n.Reason = "below-min-confidence"
+	}
+}
@@ -0,0 +1,88 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeNodes(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &SingleEmbedded{MySingleString: &SingleString{TheString: "123"}}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := DecodeNodes(ser, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(nodes, 1)
+	emb := nodes[0]
+	assert.Equal(1, emb.Field)
+	assert.Equal(WireBytes, emb.WireType)
+	assert.Equal(KindMessage, emb.Kind)
+	assert.Equal("valid-nested-message", emb.Reason)
+	assert.Nil(emb.Value)
+
+	assert.Len(emb.Children, 1)
+	str := emb.Children[0]
+	assert.Equal(KindString, str.Kind)
+	assert.Equal("123", str.Value)
+	assert.Equal("printable-utf8", str.Reason)
+	assert.True(str.Confidence > 0.5)
+}
+
+func TestDecodeNodesMinConfidence(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &TwoStrings{String_1: "hi", String_2: "a longer string"}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := DecodeNodes(ser, Options{MinConfidence: 0.9})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(nodes, 2)
+	for _, n := range nodes {
+		assert.Equal(KindBytes, n.Kind)
+		assert.Equal("below-min-confidence", n.Reason)
+	}
+	assert.Equal([]byte("hi"), nodes[0].Value)
+
+	nodes, err = DecodeNodes(ser, Options{MinConfidence: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal("hi", nodes[0].Value)
+	assert.Equal("a longer string", nodes[1].Value)
+}
+
+func TestDecodeNodesMinConfidenceVarint(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &SingleInt32{TheInt32: 7}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, err := DecodeNodes(ser, Options{MinConfidence: 0.6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(nodes, 1)
+	assert.Equal(KindUnsigned, nodes[0].Kind)
+	assert.Equal(uint64(7), nodes[0].Value)
+	assert.Equal(0.5, nodes[0].Confidence)
+	assert.Equal("below-min-confidence", nodes[0].Reason)
+}
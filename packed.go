@@ -0,0 +1,54 @@
+package protoid
+
+// Packed is the value of a packed repeated field: the raw values of its elements, which all have the same wire type.  When the field
+// was decoded with a known message type, Declared holds the elements decoded as the type it declares, such as int32 or float64.
+type Packed struct {
+	WireType WireType
+	Values   []uint64
+	Declared []interface{}
+}
+
+// InterpretPacked returns the ways a length-delimited value can be read as a packed repeated field, most plausible first.  Almost any
+// data parses as a run of varints, so the scores are low; packed fields aren't considered by Decode or DecodeNodes, and this is
+// intended for exploring values that they couldn't make sense of.
+func InterpretPacked(data []byte) []Candidate {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var cands []Candidate
+	if values, ok := packedVarints(data); ok {
+		cands = append(cands, Candidate{Kind: KindPacked, Value: Packed{WireType: WireVarint, Values: values}, Score: 0.3, Reason: "packed-varint"})
+	}
+	if len(data)%4 == 0 {
+		r := reader{buf: data}
+		values := make([]uint64, 0, len(data)/4)
+		for !r.done() {
+			values = append(values, uint64(r.readLeUint32()))
+		}
+		cands = append(cands, Candidate{Kind: KindPacked, Value: Packed{WireType: WireFixed32, Values: values}, Score: 0.25, Reason: "packed-fixed32"})
+	}
+	if len(data)%8 == 0 {
+		r := reader{buf: data}
+		values := make([]uint64, 0, len(data)/8)
+		for !r.done() {
+			values = append(values, r.readLeUint64())
+		}
+		cands = append(cands, Candidate{Kind: KindPacked, Value: Packed{WireType: WireFixed64, Values: values}, Score: 0.25, Reason: "packed-fixed64"})
+	}
+	return cands
+}
+
+// packedVarints reads data as a sequence of varints, failing if the last one is truncated.
+func packedVarints(data []byte) ([]uint64, bool) {
+	r := reader{buf: data}
+	var values []uint64
+	for !r.done() {
+		v := r.decodeVarint()
+		if r.err != nil {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
@@ -0,0 +1,33 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpretPacked(t *testing.T) {
+	assert := assert.New(t)
+
+	ri := &RepeatedInt32{MyInt32S: []int32{1, 150, 3}}
+	ser, err := proto.Marshal(ri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := DecodeNodes(ser, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := ser[fields[0].ValueOffset:fields[0].End]
+
+	cands := InterpretPacked(data)
+	if assert.Len(cands, 2) {
+		assert.Equal(Candidate{Kind: KindPacked, Value: Packed{WireType: WireVarint, Values: []uint64{1, 150, 3}}, Score: 0.3, Reason: "packed-varint"}, cands[0])
+		assert.Equal("packed-fixed32", cands[1].Reason)
+		assert.Equal(Packed{WireType: WireFixed32, Values: []uint64{0x03019601}}, cands[1].Value)
+	}
+
+	assert.Empty(InterpretPacked(nil))
+	assert.Empty(InterpretPacked([]byte{0x80}))
+}
@@ -1,9 +1,8 @@
 package protoid
 
 import (
+	"context"
 	"errors"
-	"fmt"
-	"unicode/utf8"
 )
 
 var (
@@ -15,124 +14,113 @@ var (
 	ErrNumberTooLarge = errors.New("number too large for 64 bit value")
 )
 
-type valueApplier interface {
-	mapType0(propnum int, value uint64) error
-	mapType1(propnum int, value uint64) error
-	mapType2(propnum int, value []byte) error
-	mapType5(propnum int, value uint32) error
-}
-
+// genericMapValueApplier builds the map[int]interface{} returned by Decode.  It keeps a stack of maps, one for each embedded message
+// being walked.
 type genericMapValueApplier struct {
-	m map[int]interface{}
+	stack []map[int]interface{}
+	cands [4]Candidate
 }
 
-func (va *genericMapValueApplier) mapType0(propnum int, value uint64) error {
-	va.m[propnum] = value
+func (va *genericMapValueApplier) Varint(path Path, value uint64) error {
+	va.set(path, varintCandidates(value, va.cands[:0])[0].Value)
 	return nil
 }
 
-func (va *genericMapValueApplier) mapType1(propnum int, value uint64) error {
-	va.m[propnum] = value
+func (va *genericMapValueApplier) Fixed64(path Path, value uint64) error {
+	va.set(path, fixed64Candidates(value, va.cands[:0])[0].Value)
 	return nil
 }
 
-func (va *genericMapValueApplier) mapType2(propnum int, data []byte) error {
+func (va *genericMapValueApplier) Bytes(path Path, data []byte) error {
+	spans, err := scanFields(data, nil)
+	return va.classifiedBytes(path, data, bytesCandidates(data, spans, err == nil, nil))
+}
 
-	var value interface{}
-	// try to guess the type of data
-	// first try to decode as embedded value
-	emb, err := Decode(data)
-	if err == nil {
-		value = emb
-	} else {
-		if utf8.Valid(data) {
-			// string
-			value = string(data)
-		} else {
-			// assume bytes
-			value = copyBytes(data)
+func (va *genericMapValueApplier) classifiedBytes(path Path, data []byte, cands []Candidate) error {
+	// Walk has already decided this isn't a message, so it's either a
+	// string or bytes.
+	for _, c := range cands {
+		switch c.Kind {
+		case KindString:
+			va.add(path, string(data))
+			return nil
+		case KindBytes:
+			va.add(path, copyBytes(data))
+			return nil
 		}
 	}
+	return nil
+}
+
+func (va *genericMapValueApplier) Fixed32(path Path, value uint32) error {
+	va.set(path, fixed32Candidates(value, va.cands[:0])[0].Value)
+	return nil
+}
+
+func (va *genericMapValueApplier) EnterMessage(path Path, data []byte) error {
+	va.stack = append(va.stack, make(map[int]interface{}))
+	return nil
+}
+
+func (va *genericMapValueApplier) classifiedEnterMessage(path Path, data []byte, cands []Candidate) error {
+	return va.EnterMessage(path, data)
+}
+
+func (va *genericMapValueApplier) LeaveMessage(path Path) error {
+	m := va.stack[len(va.stack)-1]
+	va.stack = va.stack[:len(va.stack)-1]
+	detectMaps(m)
+	va.add(path, m)
+	return nil
+}
+
+// set sets the value of a scalar field, overwriting any previous value.
+func (va *genericMapValueApplier) set(path Path, value interface{}) {
+	va.stack[len(va.stack)-1][path[len(path)-1]] = value
+}
 
-	if va.m[propnum] != nil {
+// add sets the value of a length-delimited field, turning it into a repeated value if it has already been set.
+func (va *genericMapValueApplier) add(path Path, value interface{}) {
+	m := va.stack[len(va.stack)-1]
+	propnum := path[len(path)-1]
+
+	if m[propnum] != nil {
 		// we already have a value here, so this must be a repeated value.
-		slice, ok := va.m[propnum].([]interface{})
+		slice, ok := m[propnum].([]interface{})
 		if ok {
 			// already a slice, simply append.
 			slice = append(slice, value)
 		} else {
 			// single value currently, change to a slice.
-			slice = append(slice, va.m[propnum])
+			slice = append(slice, m[propnum])
 			slice = append(slice, value)
 		}
-		va.m[propnum] = slice
+		m[propnum] = slice
 	} else {
-		va.m[propnum] = value
+		m[propnum] = value
 	}
-	return nil
 }
 
-func (va *genericMapValueApplier) mapType5(propnum int, value uint32) error {
-	va.m[propnum] = value
-	return nil
+// Decode decodes an arbitrary protocol buffers message into a map of field number to field value. It makes a best-effort attempt to use the most appropriate type for the values.  Embedded structs, strings, integers and more are often decoded correctly.  Varints and fixed width values are returned as signed or floating point numbers where that seems more plausible than an unsigned integer; InterpretVarint, InterpretFixed32 and InterpretFixed64 give the alternative readings.  Map fields with at least two entries are returned as a map[interface{}]interface{} keyed by the entry keys.  However due to the nature of protocol buffers, it is not always possible to do this perfectly.
+func Decode(input []byte) (map[int]interface{}, error) {
+	va := &genericMapValueApplier{}
+	return va.decode(input, &walker{})
 }
 
-// Decode decodes an arbitrary protocol buffers message into a map of field number to field value. It makes a best-effort attempt to use the most appropriate type for the values.  Embedded structs, strings, integers and more are often decoded correctly.  However due to the nature of protocol buffers, it is not always possible to do this perfectly.
-func Decode(input []byte) (map[int]interface{}, error) {
+// decode walks input with w, returning the fields found.
+func (va *genericMapValueApplier) decode(input []byte, w *walker) (map[int]interface{}, error) {
 	m := make(map[int]interface{})
+	va.stack = append(va.stack[:0], m)
+	defer clear(va.stack[:cap(va.stack)])
 
-	va := &genericMapValueApplier{m}
-
-	if err := decode(input, va); err != nil {
+	if err := w.walk(context.Background(), input, va); err != nil {
 		return nil, err
 	}
+	detectMaps(m)
 
 	return m, nil
 }
 
-func decode(input []byte, va valueApplier) error {
-
-	r := &reader{buf: input}
-
-	for !r.done() {
-		val := r.decodeVarint()
-		wiretype := val & 0x07
-		k := int(val >> 3)
-		switch wiretype {
-		case 0: // varint value (int32, int64, uint32, uint64, sint32, sint64, bool, enum)
-			v := r.decodeVarint()
-			if err := va.mapType0(k, v); err != nil {
-				return err
-			}
-		case 1: // 64 bit value (fixed64, sfixed64, double)
-			v := r.readLeUint64()
-			if err := va.mapType1(k, v); err != nil {
-				return err
-			}
-		case 2: // length-delimited value (string, bytes, embedded messages, packed repeated fields)
-			v := r.readLenDelimValue()
-			if err := va.mapType2(k, v); err != nil {
-				return err
-			}
-		case 3: // Start group (groups are deprecated)
-			return ErrNotImplemented
-		case 4: // End group (groups are deprecated)
-			return ErrNotImplemented
-		case 5: // 32-bit value (fixed32, sfixed32, float)
-			v := r.readLeUint32()
-			if err := va.mapType5(k, v); err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("unsupported wire type : %v", wiretype)
-		}
-	}
-	if r.err != nil {
-		return r.err
-	}
-	return nil
-}
-
 func copyBytes(in []byte) []byte {
 	out := make([]byte, len(in))
 	copy(out, in)
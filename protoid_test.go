@@ -1,10 +1,12 @@
 package protoid
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 func TestString(t *testing.T) {
@@ -246,3 +248,183 @@ func TestRepeatedEmbedded(t *testing.T) {
 
 	assert.Equal(expected, actual)
 }
+
+func TestStringMap(t *testing.T) {
+	assert := assert.New(t)
+
+	var ser []byte
+	for _, kv := range [][2]string{{"k1", "v1"}, {"k2", "v2"}} {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, kv[0])
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, kv[1])
+		ser = protowire.AppendTag(ser, 3, protowire.BytesType)
+		ser = protowire.AppendBytes(ser, entry)
+	}
+
+	expected := map[int]interface{}{3: map[interface{}]interface{}{"k1": "v1", "k2": "v2"}}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
+
+func TestIntMapWithDefaultKey(t *testing.T) {
+	assert := assert.New(t)
+
+	var ser []byte
+	for k, v := range []string{"zero", "one", "two"} {
+		var entry []byte
+		if k != 0 {
+			entry = protowire.AppendTag(entry, 1, protowire.VarintType)
+			entry = protowire.AppendVarint(entry, uint64(k))
+		}
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, v)
+		ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+		ser = protowire.AppendBytes(ser, entry)
+	}
+
+	expected := map[int]interface{}{1: map[interface{}]interface{}{
+		uint64(0): "zero",
+		uint64(1): "one",
+		uint64(2): "two",
+	}}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
+
+func TestMapWithDefaultValue(t *testing.T) {
+	assert := assert.New(t)
+
+	var ser []byte
+	for _, k := range []string{"a", "b", "c"} {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		if k != "a" {
+			entry = protowire.AppendTag(entry, 2, protowire.VarintType)
+			entry = protowire.AppendVarint(entry, uint64(k[0]))
+		}
+		ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+		ser = protowire.AppendBytes(ser, entry)
+	}
+
+	expected := map[int]interface{}{1: map[interface{}]interface{}{
+		"a": uint64(0),
+		"b": uint64('b'),
+		"c": uint64('c'),
+	}}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
+
+func TestMapWithSignedKeys(t *testing.T) {
+	assert := assert.New(t)
+
+	var ser []byte
+	for _, k := range []int64{-1, 2, 3} {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.VarintType)
+		entry = protowire.AppendVarint(entry, uint64(k))
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, fmt.Sprint(k))
+		ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+		ser = protowire.AppendBytes(ser, entry)
+	}
+	for _, k := range []int32{-5, 1 << 30, 7} {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.Fixed32Type)
+		entry = protowire.AppendFixed32(entry, uint32(k))
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, fmt.Sprint(k))
+		ser = protowire.AppendTag(ser, 2, protowire.BytesType)
+		ser = protowire.AppendBytes(ser, entry)
+	}
+
+	expected := map[int]interface{}{
+		1: map[interface{}]interface{}{int64(-1): "-1", int64(2): "2", int64(3): "3"},
+		2: map[interface{}]interface{}{int32(-5): "-5", int32(1 << 30): "1073741824", int32(7): "7"},
+	}
+
+	actual, err := Decode(ser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(expected, actual)
+}
+
+func deeplyNestedMessage(depth int) []byte {
+	var ser []byte
+	for i := 0; i < depth; i++ {
+		var outer []byte
+		outer = protowire.AppendTag(outer, 1, protowire.BytesType)
+		outer = protowire.AppendBytes(outer, ser)
+		outer = protowire.AppendTag(outer, 2, protowire.VarintType)
+		outer = protowire.AppendVarint(outer, uint64(i))
+		ser = outer
+	}
+	return ser
+}
+
+func stringHeavyMessage(count int) []byte {
+	var ser []byte
+	for i := 0; i < count; i++ {
+		ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+		ser = protowire.AppendString(ser, "The quick brown fox jumps over the lazy dog, again and again and again.")
+	}
+	return ser
+}
+
+func BenchmarkDecodeDeeplyNested(b *testing.B) {
+	// the time per byte should stay the same as the depth increases
+	for _, depth := range []int{50, 200, 800} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			ser := deeplyNestedMessage(depth)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(ser)))
+			for i := 0; i < b.N; i++ {
+				if _, err := Decode(ser); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecodeStringHeavy(b *testing.B) {
+	ser := stringHeavyMessage(1000)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(ser)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(ser); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeNodesDeeplyNested(b *testing.B) {
+	ser := deeplyNestedMessage(200)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(ser)))
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeNodes(ser, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package protoid
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+	"math/bits"
+	"unicode/utf8"
+)
+
+// Pseudonymize replaces every value in msg with a pseudonym derived from it with HMAC-SHA256 under key, keeping the shape of the
+// message so that it can be used to reproduce bugs without exposing the data.  Equal values get equal pseudonyms under the same key, so
+// joins across messages still work, but the original values can't be recovered without the key.
+//
+// Every pseudonym is encoded in the same number of bytes as the value it replaces, so field tags, length prefixes and the size of the
+// message are all unchanged:
+//   - Embedded messages are pseudonymized field by field.
+//   - Strings keep their length in bytes, with letters replaced by letters of the same case, digits by digits and any other non-ASCII
+//     character by letters, while ASCII punctuation and spaces are kept, so an email address still looks like one.
+//   - Other length-delimited values are replaced with random bytes, as packed repeated fields can't be told from bytes, unless the same
+//     field also occurs unpacked in the message.  That shows it to be a packed repeated field of the same wire type, and if the value
+//     parses as one each of its elements is pseudonymized in place like the numbers below, so that it still parses.
+//   - Integers keep their sign and their highest set bit, or highest clear bit if they are negative, so they stay roughly the same
+//     magnitude; in particular 0, 1, -1 and -2 are unchanged.
+//   - Fixed width values that look like floats keep their sign and exponent, and get a random mantissa.
+func Pseudonymize(msg, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errors.New("a key is required")
+	}
+	if _, err := scanFields(msg, nil); err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(msg))
+	p := pseudonymizer{mac: hmac.New(sha256.New, key)}
+	p.message(out, msg)
+	return out, nil
+}
+
+type pseudonymizer struct {
+	mac hash.Hash
+	// the key stream for the value being replaced
+	seed  []byte
+	block []byte
+	n     uint32
+	num   [8]byte
+}
+
+// message writes the pseudonymized form of msg, which must be well formed, into out, which is the same length.
+func (p *pseudonymizer) message(out, msg []byte) {
+	spans, _ := scanFields(msg, nil)
+	// the wire types of fields that occur unpacked, which are packed
+	// repeated fields wherever they also occur length-delimited
+	var unpacked map[int]WireType
+	for _, s := range spans {
+		if s.wiretype != WireBytes {
+			if unpacked == nil {
+				unpacked = make(map[int]WireType)
+			}
+			unpacked[s.num] = s.wiretype
+		}
+	}
+
+	for _, s := range spans {
+		copy(out[s.off:s.valueOff], msg[s.off:s.valueOff])
+		value := out[s.valueOff:s.end]
+		if s.wiretype != WireBytes {
+			p.number(value, s.wiretype, s.value)
+			continue
+		}
+		sub, err := scanFields(s.data, nil)
+		var cands [3]Candidate
+		switch bytesCandidates(s.data, sub, err == nil, cands[:0])[0].Kind {
+		case KindMessage:
+			p.message(value, s.data)
+		case KindString:
+			p.start(s.wiretype, s.data)
+			p.text(value, s.data)
+		default:
+			if wiretype, ok := unpacked[s.num]; ok && p.packed(value, s.data, wiretype) {
+				continue
+			}
+			p.start(s.wiretype, s.data)
+			for i := range value {
+				value[i] = p.byte()
+			}
+		}
+	}
+}
+
+// number writes a pseudonym for the value v of the given wire type into out, which holds as many bytes as v was encoded in.
+func (p *pseudonymizer) number(out []byte, wiretype WireType, v uint64) {
+	p.startNumber(wiretype, v)
+	switch wiretype {
+	case WireVarint:
+		n := p.integer(v, 64)
+		// The new value has the same bit length, so it fits in as many
+		// bytes, padded as the original was.
+		for i := range out {
+			out[i] = byte(n&0x7f) | 0x80
+			n >>= 7
+		}
+		out[len(out)-1] &^= 0x80
+	case WireFixed64:
+		n := p.integer(v, 64)
+		if InterpretFixed64(v)[0].Kind == KindFloat {
+			n = p.mantissa(v, 52)
+		}
+		binary.LittleEndian.PutUint64(out, n)
+	case WireFixed32:
+		n := p.integer(v, 32)
+		if InterpretFixed32(uint32(v))[0].Kind == KindFloat {
+			n = p.mantissa(v, 23)
+		}
+		binary.LittleEndian.PutUint32(out, uint32(n))
+	}
+}
+
+// packed writes a pseudonym for a packed repeated field of the given wire type into out, which is the same length as data, replacing
+// each element in place so that it keeps its encoded size.  It returns false, without writing anything, if data doesn't parse as one.
+func (p *pseudonymizer) packed(out, data []byte, wiretype WireType) bool {
+	switch wiretype {
+	case WireVarint:
+		if _, ok := packedVarints(data); !ok {
+			return false
+		}
+	case WireFixed64:
+		if len(data)%8 != 0 {
+			return false
+		}
+	case WireFixed32:
+		if len(data)%4 != 0 {
+			return false
+		}
+	}
+	r := reader{buf: data}
+	for !r.done() {
+		start := r.off
+		var v uint64
+		switch wiretype {
+		case WireVarint:
+			v = r.decodeVarint()
+		case WireFixed64:
+			v = r.readLeUint64()
+		case WireFixed32:
+			v = uint64(r.readLeUint32())
+		}
+		p.number(out[start:r.off], wiretype, v)
+	}
+	return true
+}
+
+// start begins the key stream for a value, seeding it with the HMAC of the value so that equal values are replaced alike.
+func (p *pseudonymizer) start(wiretype WireType, value []byte) {
+	p.mac.Reset()
+	p.mac.Write([]byte{byte(wiretype)})
+	p.mac.Write(value)
+	p.seed = p.mac.Sum(p.seed[:0])
+	p.block = p.block[:0]
+	p.n = 0
+}
+
+// startNumber begins the key stream for a numeric value.
+func (p *pseudonymizer) startNumber(wiretype WireType, v uint64) {
+	p.start(wiretype, binary.LittleEndian.AppendUint64(p.num[:0], v))
+}
+
+// byte returns the next byte of the key stream, which is made up of the HMACs of the seed followed by a counter.
+func (p *pseudonymizer) byte() byte {
+	if len(p.block) == 0 {
+		p.mac.Reset()
+		p.mac.Write(p.seed)
+		p.mac.Write(binary.BigEndian.AppendUint32(nil, p.n))
+		p.block = p.mac.Sum(p.block[:0])
+		p.n++
+	}
+	b := p.block[0]
+	p.block = p.block[1:]
+	return b
+}
+
+func (p *pseudonymizer) uint64() uint64 {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<8 | uint64(p.byte())
+	}
+	return v
+}
+
+// integer replaces the bits of v below its highest set bit, treating it as a two's complement integer of the given size.  Negative
+// numbers have the bits below their highest clear bit replaced instead, so that they stay negative and in range.
+func (p *pseudonymizer) integer(v uint64, size int) uint64 {
+	mask := uint64(math.MaxUint64) >> (64 - size)
+	if v&(1<<(size-1)) != 0 {
+		return ^p.integer(^v&mask, size) & mask
+	}
+	if v <= 1 {
+		return v
+	}
+	top := uint64(1) << (bits.Len64(v) - 1)
+	return top | p.uint64()&(top-1)
+}
+
+// mantissa replaces the low mantissaBits bits of the float v.
+func (p *pseudonymizer) mantissa(v uint64, mantissaBits int) uint64 {
+	mask := uint64(1)<<mantissaBits - 1
+	return v&^mask | p.uint64()&mask
+}
+
+// text writes a pseudonym for the string s into out, which is the same length.
+func (p *pseudonymizer) text(out, s []byte) {
+	letter := func(base byte) byte { return base + p.byte()%26 }
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			out[i] = letter('a')
+		case c >= 'A' && c <= 'Z':
+			out[i] = letter('A')
+		case c >= '0' && c <= '9':
+			out[i] = '0' + p.byte()%10
+		case c < utf8.RuneSelf:
+			out[i] = c
+		default:
+			// replace a multibyte character with as many letters
+			_, size := utf8.DecodeRune(s[i:])
+			for j := 0; j < size; j++ {
+				out[i+j] = letter('a')
+			}
+			i += size
+			continue
+		}
+		i++
+	}
+}
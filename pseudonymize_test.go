@@ -0,0 +1,147 @@
+package protoid
+
+import (
+	"encoding/binary"
+	"math"
+	"regexp"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestPseudonymize(t *testing.T) {
+	assert := assert.New(t)
+	key := []byte("key")
+
+	pseudonymize := func(m, out proto.Message) []byte {
+		ser, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := Pseudonymize(ser, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(p, len(ser))
+		if err := proto.Unmarshal(p, out); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	// equal values get equal pseudonyms, that keep the shape of the text
+	var two TwoStrings
+	first := pseudonymize(&TwoStrings{String_1: "Bob.Smith@example.com", String_2: "Bob.Smith@example.com"}, &two)
+	assert.Equal(two.String_1, two.String_2)
+	assert.NotEqual("Bob.Smith@example.com", two.String_1)
+	assert.Regexp(regexp.MustCompile(`^[A-Z][a-z]{2}\.[A-Z][a-z]{4}@[a-z]{7}\.[a-z]{3}$`), two.String_1)
+	assert.Equal(first, pseudonymize(&TwoStrings{String_1: "Bob.Smith@example.com", String_2: "Bob.Smith@example.com"}, &two))
+
+	var e SingleEmbedded
+	pseudonymize(&SingleEmbedded{MySingleString: &SingleString{TheString: "héllo 123"}}, &e)
+	if assert.NotNil(e.MySingleString) {
+		assert.Regexp(regexp.MustCompile(`^[a-z]{6} [0-9]{3}$`), e.MySingleString.TheString)
+	}
+
+	for _, v := range []int32{0, 1, 1000, -5, math.MinInt32} {
+		var i SingleInt32
+		pseudonymize(&SingleInt32{TheInt32: v}, &i)
+		switch {
+		case v == 1000:
+			assert.True(i.TheInt32 >= 512 && i.TheInt32 < 1024, i.TheInt32)
+		case v == -5:
+			assert.True(i.TheInt32 <= -5 && i.TheInt32 >= -8, i.TheInt32)
+		case v == math.MinInt32:
+			assert.True(i.TheInt32 < -1<<30, i.TheInt32)
+		default:
+			assert.Equal(v, i.TheInt32)
+		}
+	}
+
+	// a double keeps its exponent
+	msg := appendLeUint64(appendTag(nil, 1, WireFixed64), math.Float64bits(1234.5))
+	p, err := Pseudonymize(msg, key)
+	assert.NoError(err)
+	f := math.Float64frombits(binary.LittleEndian.Uint64(p[1:]))
+	assert.True(f >= 1024 && f < 2048, f)
+
+	// a padded varint stays padded
+	p, err = Pseudonymize([]byte{0x08, 0x85, 0x80, 0x00}, key)
+	assert.NoError(err)
+	assert.Len(p, 4)
+	n, err := DecodeNodes(p, Options{})
+	if assert.NoError(err) && assert.Len(n, 1) {
+		assert.Equal(3, n[0].End-n[0].ValueOffset)
+	}
+
+	other, err := Pseudonymize(first, []byte("other key"))
+	assert.NoError(err)
+	assert.NotEqual(first, other)
+
+	_, err = Pseudonymize(first, nil)
+	assert.Error(err)
+	_, err = Pseudonymize([]byte{0x0a, 0x05}, key)
+	assert.Error(err)
+}
+
+func TestPseudonymizePacked(t *testing.T) {
+	assert := assert.New(t)
+
+	values := []int32{150, 3, 270, 86942, 7, -1, math.MinInt32}
+	ser, err := proto.Marshal(&RepeatedInt32{MyInt32S: values})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the field also occurring unpacked shows that it is a packed field
+	ser = append(ser, varints(1, 9)...)
+	values = append(values, 9)
+	for _, key := range []string{"a", "b", "c", "d", "e", "key"} {
+		p, err := Pseudonymize(ser, []byte(key))
+		if !assert.NoError(err, key) {
+			continue
+		}
+		assert.Len(p, len(ser), key)
+		var out RepeatedInt32
+		if !assert.NoError(proto.Unmarshal(p, &out), key) || !assert.Len(out.MyInt32S, len(values), key) {
+			continue
+		}
+		for i, v := range values {
+			// the same magnitude and sign
+			assert.Equal(v < 0, out.MyInt32S[i] < 0, key)
+			assert.Equal(protowire.SizeVarint(uint64(v)), protowire.SizeVarint(uint64(out.MyInt32S[i])), key)
+		}
+		assert.Contains([]int32{2, 3}, out.MyInt32S[1], key)
+		assert.Equal(int32(-1), out.MyInt32S[5], key)
+	}
+
+	// an element is pseudonymized as it would be outside a packed field
+	single, err := proto.Marshal(&SingleInt32{TheInt32: 86942})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Pseudonymize(ser, []byte("key"))
+	assert.NoError(err)
+	ps, _ := Pseudonymize(single, []byte("key"))
+	var out RepeatedInt32
+	var outSingle SingleInt32
+	if assert.NoError(proto.Unmarshal(p, &out)) && assert.NoError(proto.Unmarshal(ps, &outSingle)) {
+		assert.Equal(outSingle.TheInt32, out.MyInt32S[3])
+	}
+
+	// without that, bytes that happen to parse as packed varints are
+	// replaced with random bytes, rather than keeping their structure
+	blob := []byte{0xde, 0xad, 0xbe, 0xef, 0x00, 0x01, 0x00, 0x01, 0x9a, 0x10, 0x00, 0x00, 0xff, 0x01, 0x00, 0x42}
+	ser = protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), blob)
+	p, err = Pseudonymize(ser, []byte("key"))
+	if assert.NoError(err) && assert.Len(p, len(ser)) {
+		same := 0
+		for i, b := range p[2:] {
+			if b == blob[i] {
+				same++
+			}
+		}
+		assert.Less(same, 3)
+	}
+}
@@ -0,0 +1,209 @@
+package protoid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// Raw is an encoded message that describes its decoded fields when it is logged, printed or marshalled to JSON, so that payloads from
+// unknown producers can be logged as they are.  Fields are keyed by field number, with the values protoid guesses for them.  Anything
+// that couldn't be decoded is reported under the key "error" along with the fields that could.
+type Raw []byte
+
+var (
+	_ slog.LogValuer = Raw(nil)
+	_ fmt.Formatter  = Raw(nil)
+	_ json.Marshaler = Raw(nil)
+)
+
+// LogValue returns the fields of r as a group keyed by field number, with embedded messages as nested groups.  A field that occurs
+// more than once has a list of its values.
+func (r Raw) LogValue() slog.Value {
+	t := DecodeTree(r, Options{})
+	attrs := rawGroup(t.Fields)
+	if t.Err != nil {
+		attrs = append(attrs, slog.String("error", t.Err.Error()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func rawGroup(nodes []*Node) []slog.Attr {
+	var attrs []slog.Attr
+	for _, field := range groupFields(nodes) {
+		key := strconv.Itoa(field[0].Field)
+		if len(field) == 1 && field[0].Kind == KindMessage {
+			attrs = append(attrs, slog.Attr{Key: key, Value: slog.GroupValue(rawGroup(field[0].Children)...)})
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, rawFieldValue(field)))
+	}
+	return attrs
+}
+
+// rawFieldValue returns the value of a field for logging, or a list of its values if it occurs more than once.
+func rawFieldValue(field []*Node) interface{} {
+	if len(field) == 1 {
+		return rawValue(field[0])
+	}
+	values := make([]interface{}, len(field))
+	for i, n := range field {
+		values[i] = rawValue(n)
+	}
+	return values
+}
+
+// rawValue returns the value of n for logging, with embedded messages as maps keyed by field number.
+func rawValue(n *Node) interface{} {
+	if n.Kind == KindMessage {
+		m := make(map[string]interface{})
+		for _, field := range groupFields(n.Children) {
+			m[strconv.Itoa(field[0].Field)] = rawFieldValue(field)
+		}
+		return m
+	}
+	if p, ok := n.Value.(Packed); ok {
+		if p.Declared != nil {
+			return p.Declared
+		}
+		return p.Values
+	}
+	return n.Value
+}
+
+// groupFields groups the occurrences of each field in nodes, in the order that the fields first appear.
+func groupFields(nodes []*Node) [][]*Node {
+	var fields [][]*Node
+	index := make(map[int]int)
+	for _, n := range nodes {
+		i, ok := index[n.Field]
+		if !ok {
+			i = len(fields)
+			index[n.Field] = i
+			fields = append(fields, nil)
+		}
+		fields[i] = append(fields[i], n)
+	}
+	return fields
+}
+
+// Format implements fmt.Formatter.  %v and %s print the fields of r on a single line in the style of the protocol buffers text format,
+// and %+v prints them as an indented tree.  %x and %X print an annotated hex dump, as written by WriteHexDump.  Long values are
+// truncated as by FormatValue.
+func (r Raw) Format(f fmt.State, verb rune) {
+	t := DecodeTree(r, Options{})
+	var b strings.Builder
+	switch verb {
+	case 'v', 's':
+		if f.Flag('+') {
+			writeRawTree(&b, t.Fields, 0)
+			if t.Err != nil {
+				fmt.Fprintf(&b, "!! %v\n", t.Err)
+			}
+			fmt.Fprint(f, strings.TrimSuffix(b.String(), "\n"))
+			return
+		}
+		writeRawLine(&b, t.Fields)
+		if t.Err != nil {
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "!! %v", t.Err)
+		}
+	case 'x', 'X':
+		WriteHexDump(&b, t, HexDumpOptions{})
+		fmt.Fprint(f, strings.TrimSuffix(b.String(), "\n"))
+		return
+	default:
+		fmt.Fprintf(&b, "%%!%c(protoid.Raw=%x)", verb, []byte(r))
+	}
+	fmt.Fprint(f, b.String())
+}
+
+func writeRawLine(b *strings.Builder, nodes []*Node) {
+	for i, n := range nodes {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if n.Kind == KindMessage {
+			fmt.Fprintf(b, "%d:{", n.Field)
+			writeRawLine(b, n.Children)
+			b.WriteByte('}')
+			continue
+		}
+		fmt.Fprintf(b, "%d:%s", n.Field, FormatValue(n.Value))
+	}
+}
+
+func writeRawTree(b *strings.Builder, nodes []*Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, n := range nodes {
+		if n.Kind == KindMessage {
+			fmt.Fprintf(b, "%s%d {\n", indent, n.Field)
+			writeRawTree(b, n.Children, depth+1)
+			fmt.Fprintf(b, "%s}\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s%d: %s\n", indent, n.Field, FormatValue(n.Value))
+	}
+}
+
+// MarshalJSON encodes the fields of r as a JSON object keyed by field number, in the order that the fields first appear.  Embedded
+// messages are nested objects, a field that occurs more than once has an array of its values, and bytes are base64 encoded.
+func (r Raw) MarshalJSON() ([]byte, error) {
+	t := DecodeTree(r, Options{})
+	var buf bytes.Buffer
+	if err := writeRawJSON(&buf, t.Fields, t.Err); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeRawJSON(buf *bytes.Buffer, nodes []*Node, decodeErr error) error {
+	buf.WriteByte('{')
+	for i, field := range groupFields(nodes) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "%q:", strconv.Itoa(field[0].Field))
+		if len(field) > 1 {
+			buf.WriteByte('[')
+		}
+		for j, n := range field {
+			if j > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeRawJSONValue(buf, n); err != nil {
+				return err
+			}
+		}
+		if len(field) > 1 {
+			buf.WriteByte(']')
+		}
+	}
+	if decodeErr != nil {
+		if len(nodes) > 0 {
+			buf.WriteByte(',')
+		}
+		msg, _ := json.Marshal(decodeErr.Error())
+		buf.WriteString(`"error":`)
+		buf.Write(msg)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeRawJSONValue(buf *bytes.Buffer, n *Node) error {
+	if n.Kind == KindMessage {
+		return writeRawJSON(buf, n.Children, nil)
+	}
+	data, err := json.Marshal(rawValue(n))
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	return nil
+}
@@ -0,0 +1,52 @@
+package protoid
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaw(t *testing.T) {
+	assert := assert.New(t)
+
+	ser, err := proto.Marshal(&SingleEmbedded{MySingleString: &SingleString{TheString: "abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Raw(append(ser, 0x10, 0x96, 0x01, 0x10, 0x96, 0x01))
+
+	assert.Equal(`1:{1:"abc"} 2:150 2:150`, fmt.Sprintf("%v", r))
+	assert.Equal(`1:{1:"abc"} 2:150 2:150`, fmt.Sprint(r))
+	assert.Equal("1 {\n  1: \"abc\"\n}\n2: 150\n2: 150", fmt.Sprintf("%+v", r))
+	hex := fmt.Sprintf("%x", r)
+	assert.True(strings.HasPrefix(hex, "00000000  0a "), hex)
+	assert.Contains(hex, `value  "abc"`)
+	assert.Equal("%!d(protoid.Raw=0a)", fmt.Sprintf("%d", Raw{0x0a}))
+
+	data, err := json.Marshal(r)
+	assert.NoError(err)
+	assert.JSONEq(`{"1":{"1":"abc"},"2":[150,150]}`, string(data))
+
+	var buf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("received", "payload", r)
+	var entry struct {
+		Payload map[string]interface{}
+	}
+	if assert.NoError(json.Unmarshal(buf.Bytes(), &entry)) {
+		assert.Equal(map[string]interface{}{"1": map[string]interface{}{"1": "abc"}, "2": []interface{}{150.0, 150.0}}, entry.Payload)
+	}
+
+	// what can be decoded is shown, along with why the rest can't
+	malformed := Raw(append(ser, 0x0a, 0x05))
+	assert.Equal(`1:{1:"abc"} !! unexpected end of input`, fmt.Sprint(malformed))
+	data, err = json.Marshal(malformed)
+	assert.NoError(err)
+	assert.JSONEq(`{"1":{"1":"abc"},"error":"unexpected end of input"}`, string(data))
+	assert.Equal(slog.StringValue("unexpected end of input"), malformed.LogValue().Group()[1].Value)
+}
@@ -4,6 +4,7 @@ import "encoding/binary"
 
 type reader struct {
 	buf []byte
+	off int // how many bytes have been consumed
 	err error
 }
 
@@ -26,6 +27,7 @@ func (r *reader) readLeUint32() uint32 {
 	}
 	v := binary.LittleEndian.Uint32(r.buf)
 	r.buf = r.buf[4:]
+	r.off += 4
 	return v
 }
 
@@ -40,6 +42,7 @@ func (r *reader) readLeUint64() uint64 {
 	}
 	v := binary.LittleEndian.Uint64(r.buf)
 	r.buf = r.buf[8:]
+	r.off += 8
 	return v
 }
 
@@ -56,6 +59,7 @@ func (r *reader) readLenDelimValue() []byte {
 	}
 	data := r.buf[0:l]
 	r.buf = r.buf[l:]
+	r.off += int(l)
 	return data
 }
 
@@ -76,6 +80,7 @@ func (r *reader) decodeVarint() uint64 {
 		val |= (b & 0x7F) << shift
 		if (b & 0x80) == 0 {
 			r.buf = r.buf[l:]
+			r.off += l
 			return val
 		}
 	}
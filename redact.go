@@ -0,0 +1,199 @@
+package protoid
+
+import (
+	"regexp"
+	"slices"
+)
+
+// RedactOptions controls what Redact removes from a message.
+type RedactOptions struct {
+	// Paths are the fields to redact.  Every occurrence of each field is redacted, along with everything inside it.
+	Paths []Path
+	// Heuristics also redacts personal data anywhere in the message: email addresses, phone numbers and payment card numbers.  The raw
+	// bytes of every length-delimited value are searched, whether it looks like a string, bytes or an embedded message, so that text
+	// that isn't valid UTF-8 or happens to parse as a message isn't let through.  Embedded messages are searched after their own
+	// fields have been redacted, and any that still match are masked as text.
+	Heuristics bool
+	// Mask, if set, replaces redacted values instead of removing their fields, so that the message keeps its shape.  A value flagged
+	// by the heuristics only has the sensitive parts of it replaced.  Other length-delimited values are replaced with Mask as a whole,
+	// except that embedded messages are emptied, and numbers are set to zero.
+	Mask string
+}
+
+// Redaction is a value that Redact removed or masked.  Reason is "path" for a field that was listed in RedactOptions.Paths, or the kind
+// of personal data found in a string: "email", "phone" or "card".
+type Redaction struct {
+	Path   Path
+	Reason string
+}
+
+// Redact returns a copy of msg with the fields chosen by opts removed or masked, along with a list of what was redacted.  Embedded
+// messages that change are re-encoded with corrected length prefixes, so the result is still a valid message; everything else is
+// copied exactly as it was encoded.  An error is returned if msg is malformed.
+func Redact(msg []byte, opts RedactOptions) ([]byte, []Redaction, error) {
+	if _, err := scanFields(msg, nil); err != nil {
+		return nil, nil, err
+	}
+	r := redactor{opts: opts}
+	out, _ := r.message(nil, msg, nil)
+	return out, r.redactions, nil
+}
+
+type redactor struct {
+	opts       RedactOptions
+	redactions []Redaction
+}
+
+// message appends msg, which must be well formed, to buf with its sensitive fields redacted, and returns whether there were any.
+func (r *redactor) message(buf, msg []byte, path Path) ([]byte, bool) {
+	spans, _ := scanFields(msg, nil)
+	changed := false
+	for _, s := range spans {
+		p := append(path[:len(path):len(path)], s.num)
+		if slices.ContainsFunc(r.opts.Paths, func(rp Path) bool { return slices.Equal(rp, p) }) {
+			r.redactions = append(r.redactions, Redaction{Path: p, Reason: "path"})
+			if r.opts.Mask != "" {
+				buf = r.mask(buf, s)
+			}
+			changed = true
+			continue
+		}
+
+		if s.wiretype == WireBytes {
+			sub, err := scanFields(s.data, nil)
+			var scratch [3]Candidate
+			cands := bytesCandidates(s.data, sub, err == nil, scratch[:0])
+			// A path below this field means it must be a message, whatever
+			// the best guess for it is.
+			walk := cands[0].Kind == KindMessage || r.below(p) && slices.ContainsFunc(cands, func(c Candidate) bool { return c.Kind == KindMessage })
+
+			value, walked := s.data, false
+			if walk {
+				value, walked = r.message(nil, s.data, p)
+			}
+			// Personal data left in the bytes of a walked message, such as an
+			// email address spread over several fields, shows that it is
+			// really text.
+			if found := r.sensitive(value, p); len(found) > 0 {
+				if r.opts.Mask != "" {
+					buf = appendLenDelimValue(appendTag(buf, s.num, WireBytes), maskSpans(value, found, r.opts.Mask))
+				}
+				changed = true
+				continue
+			}
+			if walked {
+				buf = appendLenDelimValue(appendTag(buf, s.num, WireBytes), value)
+				changed = true
+				continue
+			}
+		}
+		buf = append(buf, msg[s.off:s.end]...)
+	}
+	return buf, changed
+}
+
+// below returns whether any of the paths to redact are inside the field at path.
+func (r *redactor) below(path Path) bool {
+	return slices.ContainsFunc(r.opts.Paths, func(rp Path) bool { return len(rp) > len(path) && slices.Equal(rp[:len(path)], path) })
+}
+
+// mask appends the field s with its value replaced.
+func (r *redactor) mask(buf []byte, s fieldSpan) []byte {
+	buf = appendTag(buf, s.num, s.wiretype)
+	switch s.wiretype {
+	case WireVarint:
+		return appendVarint(buf, 0)
+	case WireFixed64:
+		return appendLeUint64(buf, 0)
+	case WireFixed32:
+		return appendLeUint32(buf, 0)
+	}
+	sub, err := scanFields(s.data, nil)
+	var cands [3]Candidate
+	if bytesCandidates(s.data, sub, err == nil, cands[:0])[0].Kind == KindMessage {
+		return appendLenDelimValue(buf, nil)
+	}
+	return appendLenDelimValue(buf, []byte(r.opts.Mask))
+}
+
+// sensitive returns the parts of the value at path that look like personal data, if the heuristics are enabled, recording each of
+// them.
+func (r *redactor) sensitive(text []byte, path Path) []sensitiveSpan {
+	if !r.opts.Heuristics {
+		return nil
+	}
+	found := findSensitive(text)
+	for _, f := range found {
+		r.redactions = append(r.redactions, Redaction{Path: path, Reason: f.reason})
+	}
+	return found
+}
+
+// sensitiveSpan is a part of a string that looks like personal data.
+type sensitiveSpan struct {
+	start, end int
+	reason     string
+}
+
+// sensitivePatterns match personal data in strings.  Earlier patterns take precedence where matches overlap, so card numbers aren't
+// mistaken for phone numbers.
+var sensitivePatterns = []struct {
+	reason string
+	re     *regexp.Regexp
+	valid  func(match []byte) bool
+}{
+	{"email", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`), nil},
+	{"card", regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), luhnValid},
+	{"phone", regexp.MustCompile(`(?:\+\d{1,3}[ -]?|\b0)\d(?:[ -]?\d){7,12}\b`), nil},
+}
+
+// findSensitive returns the parts of text that look like personal data, in order.
+func findSensitive(text []byte) []sensitiveSpan {
+	var found []sensitiveSpan
+	for _, pat := range sensitivePatterns {
+		for _, m := range pat.re.FindAllIndex(text, -1) {
+			if pat.valid != nil && !pat.valid(text[m[0]:m[1]]) {
+				continue
+			}
+			overlaps := slices.ContainsFunc(found, func(f sensitiveSpan) bool { return m[0] < f.end && f.start < m[1] })
+			if !overlaps {
+				found = append(found, sensitiveSpan{start: m[0], end: m[1], reason: pat.reason})
+			}
+		}
+	}
+	slices.SortFunc(found, func(a, b sensitiveSpan) int { return a.start - b.start })
+	return found
+}
+
+// maskSpans returns text with each of the spans, which must be in order, replaced with mask.
+func maskSpans(text []byte, spans []sensitiveSpan, mask string) []byte {
+	var out []byte
+	last := 0
+	for _, s := range spans {
+		out = append(out, text[last:s.start]...)
+		out = append(out, mask...)
+		last = s.end
+	}
+	return append(out, text[last:]...)
+}
+
+// luhnValid returns whether the digits in number pass the Luhn check used by payment card numbers.
+func luhnValid(number []byte) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
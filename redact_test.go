@@ -0,0 +1,121 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRedact(t *testing.T) {
+	assert := assert.New(t)
+
+	redact := func(m, out proto.Message, opts RedactOptions) []Redaction {
+		ser, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		redacted, redactions, err := Redact(ser, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := proto.Unmarshal(redacted, out); err != nil {
+			t.Fatal(err)
+		}
+		return redactions
+	}
+
+	var two TwoStrings
+	redactions := redact(&TwoStrings{String_1: "secret", String_2: "public"}, &two, RedactOptions{Paths: []Path{{1}}})
+	assert.Equal(TwoStrings{String_2: "public"}, two)
+	assert.Equal([]Redaction{{Path: Path{1}, Reason: "path"}}, redactions)
+
+	redact(&TwoStrings{String_1: "secret", String_2: "public"}, &two, RedactOptions{Paths: []Path{{1}}, Mask: "xxx"})
+	assert.Equal(TwoStrings{String_1: "xxx", String_2: "public"}, two)
+
+	var i SingleInt32
+	redact(&SingleInt32{TheInt32: 5}, &i, RedactOptions{Paths: []Path{{1}}, Mask: "xxx"})
+	assert.Equal(int32(0), i.TheInt32)
+
+	// the length prefixes of the embedded message are corrected
+	text := "call bob@example.com on +44 20 7946 0958, card 4111 1111 1111 1111, order 4111 1111 1111 1112"
+	var e SingleEmbedded
+	redactions = redact(&SingleEmbedded{MySingleString: &SingleString{TheString: text}}, &e, RedactOptions{Heuristics: true, Mask: "[redacted]"})
+	if assert.NotNil(e.MySingleString) {
+		assert.Equal("call [redacted] on [redacted], card [redacted], order 4111 1111 1111 1112", e.MySingleString.TheString)
+	}
+	assert.Equal([]Redaction{
+		{Path: Path{1, 1}, Reason: "email"},
+		{Path: Path{1, 1}, Reason: "phone"},
+		{Path: Path{1, 1}, Reason: "card"},
+	}, redactions)
+
+	e = SingleEmbedded{}
+	redact(&SingleEmbedded{MySingleString: &SingleString{TheString: text}}, &e, RedactOptions{Heuristics: true})
+	if assert.NotNil(e.MySingleString) {
+		assert.Empty(e.MySingleString.TheString)
+	}
+
+	// nothing is redacted without heuristics, and the message is unchanged
+	ser, _ := proto.Marshal(&SingleEmbedded{MySingleString: &SingleString{TheString: text}})
+	out, redactions, err := Redact(ser, RedactOptions{})
+	assert.NoError(err)
+	assert.Equal(ser, out)
+	assert.Empty(redactions)
+
+	_, _, err = Redact([]byte{0x0a, 0x05}, RedactOptions{})
+	assert.Error(err)
+}
+
+func TestRedactBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	// Latin-1 text isn't valid UTF-8, so it is guessed to be bytes, but is
+	// still searched
+	latin1 := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte("Jos\xe9 bob@example.com"))
+	assert.Equal(KindBytes, InterpretBytes([]byte("Jos\xe9 bob@example.com"))[0].Kind)
+	out, redactions, err := Redact(latin1, RedactOptions{Heuristics: true, Mask: "xxx"})
+	assert.NoError(err)
+	assert.Equal(protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte("Jos\xe9 xxx")), out)
+	assert.Equal([]Redaction{{Path: Path{1}, Reason: "email"}}, redactions)
+
+	out, _, err = Redact(latin1, RedactOptions{Heuristics: true})
+	assert.NoError(err)
+	assert.Empty(out)
+}
+
+func TestRedactTextThatParsesAsMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	text := []byte("* Please contact our support team: xi@mail.io")
+	assert.Equal(KindMessage, InterpretBytes(text)[0].Kind)
+	msg := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), text)
+
+	out, redactions, err := Redact(msg, RedactOptions{Heuristics: true, Mask: "[x]"})
+	assert.NoError(err)
+	assert.Equal(protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte("* Please contact our support team: [x]")), out)
+	assert.Equal([]Redaction{{Path: Path{1}, Reason: "email"}}, redactions)
+}
+
+func TestRedactPathBelowString(t *testing.T) {
+	assert := assert.New(t)
+
+	// "(a" is field 5 with the value 97, but is more likely a string
+	inner := []byte("(a")
+	assert.Equal(KindString, InterpretBytes(inner)[0].Kind)
+	msg := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), inner)
+	msg = append(msg, varints(2, 1)...)
+
+	out, redactions, err := Redact(msg, RedactOptions{Paths: []Path{{1, 5}}})
+	assert.NoError(err)
+	assert.Equal(append([]byte{0x0a, 0x00}, varints(2, 1)...), out)
+	assert.Equal([]Redaction{{Path: Path{1, 5}, Reason: "path"}}, redactions)
+
+	// a path below a field that doesn't parse as a message leaves it alone
+	msg = protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte("("))
+	out, redactions, err = Redact(msg, RedactOptions{Paths: []Path{{1, 5}}})
+	assert.NoError(err)
+	assert.Equal(msg, out)
+	assert.Empty(redactions)
+}
@@ -0,0 +1,125 @@
+package protoid
+
+import (
+	"math"
+	"slices"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// reasonDeclared is the reason given for a value decoded as its declared type.
+const reasonDeclared = "declared"
+
+// applySchema replaces the guesses for the fields of nodes that md declares with their declared types and names, decoding embedded
+// messages that weren't recognised as such.  input is the message that the nodes were decoded from.  Fields that md doesn't declare, or
+// whose wire type contradicts their declaration, are left as they were.
+func applySchema(input []byte, nodes []*Node, md protoreflect.MessageDescriptor, opts Options) {
+	for _, n := range nodes {
+		fd := md.Fields().ByNumber(protoreflect.FieldNumber(n.Field))
+		if fd == nil {
+			continue
+		}
+		data := input[n.ValueOffset:n.End]
+		expected := kindWireType(fd.Kind())
+
+		var c Candidate
+		switch {
+		case n.WireType == expected && fd.Kind() == protoreflect.MessageKind:
+			if n.Kind != KindMessage {
+				sub := opts
+				sub.Type = fd.Message()
+				children, err := DecodeValue(input, n, sub)
+				if err != nil {
+					// a malformed embedded message is better shown as a guess
+					continue
+				}
+				n.Children = children
+			} else {
+				applySchema(input, n.Children, fd.Message(), opts)
+			}
+			c = Candidate{Kind: KindMessage}
+		case n.WireType == expected:
+			c = declaredValue(fd, n.WireType, data)
+			n.Children = nil
+		case n.WireType == WireBytes && fd.IsList() && expected != WireBytes:
+			packed, ok := declaredPacked(fd, expected, data)
+			if !ok {
+				continue
+			}
+			c = Candidate{Kind: KindPacked, Value: packed}
+			n.Children = nil
+		default:
+			continue
+		}
+		c.Score, c.Reason = 1, reasonDeclared
+
+		n.Name = string(fd.Name())
+		n.Kind, n.Value, n.Confidence, n.Reason = c.Kind, c.Value, c.Score, c.Reason
+		// keep the guesses as alternatives, after the declared type
+		n.Candidates = slices.Insert(slices.DeleteFunc(n.Candidates, func(g Candidate) bool { return g.Kind == c.Kind }), 0, c)
+	}
+}
+
+// declaredValue decodes a value of the given wire type as the type fd declares.
+func declaredValue(fd protoreflect.FieldDescriptor, wiretype WireType, data []byte) Candidate {
+	r := reader{buf: data}
+	var raw uint64
+	switch wiretype {
+	case WireVarint:
+		raw = r.decodeVarint()
+	case WireFixed64:
+		raw = r.readLeUint64()
+	case WireFixed32:
+		raw = uint64(r.readLeUint32())
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return Candidate{Kind: KindBool, Value: raw != 0}
+	case protoreflect.Int32Kind, protoreflect.EnumKind, protoreflect.Sfixed32Kind:
+		return Candidate{Kind: KindSigned, Value: int32(raw)}
+	case protoreflect.Int64Kind, protoreflect.Sfixed64Kind:
+		return Candidate{Kind: KindSigned, Value: int64(raw)}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return Candidate{Kind: KindUnsigned, Value: uint32(raw)}
+	case protoreflect.Sint32Kind:
+		return Candidate{Kind: KindZigzag, Value: int32(raw>>1) ^ -int32(raw&1)}
+	case protoreflect.Sint64Kind:
+		return Candidate{Kind: KindZigzag, Value: int64(raw>>1) ^ -int64(raw&1)}
+	case protoreflect.FloatKind:
+		return Candidate{Kind: KindFloat, Value: math.Float32frombits(uint32(raw))}
+	case protoreflect.DoubleKind:
+		return Candidate{Kind: KindFloat, Value: math.Float64frombits(raw)}
+	case protoreflect.StringKind:
+		return Candidate{Kind: KindString, Value: string(data)}
+	case protoreflect.BytesKind:
+		return Candidate{Kind: KindBytes, Value: copyBytes(data)}
+	default:
+		return Candidate{Kind: KindUnsigned, Value: raw}
+	}
+}
+
+// declaredPacked decodes the elements of a packed repeated field as the type fd declares, returning false if data isn't a whole number
+// of them.
+func declaredPacked(fd protoreflect.FieldDescriptor, wiretype WireType, data []byte) (Packed, bool) {
+	p := Packed{WireType: wiretype}
+	r := reader{buf: data}
+	for !r.done() {
+		start := r.off
+		var raw uint64
+		switch wiretype {
+		case WireVarint:
+			raw = r.decodeVarint()
+		case WireFixed64:
+			raw = r.readLeUint64()
+		case WireFixed32:
+			raw = uint64(r.readLeUint32())
+		}
+		if r.err != nil {
+			return Packed{}, false
+		}
+		p.Values = append(p.Values, raw)
+		p.Declared = append(p.Declared, declaredValue(fd, wiretype, data[start:r.off]).Value)
+	}
+	return p, true
+}
@@ -0,0 +1,108 @@
+package protoid
+
+import (
+	"testing"
+
+	protov1 "github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDecodeNodesWithType(t *testing.T) {
+	assert := assert.New(t)
+
+	decode := func(m protov1.Message, typ protov1.Message, extra ...byte) []*Node {
+		ser, err := protov1.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, err := DecodeNodes(append(ser, extra...), Options{Type: descriptorOf(typ)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nodes
+	}
+
+	// embedded messages are named all the way down
+	nodes := decode(&SingleEmbedded{MySingleString: &SingleString{TheString: "123"}}, &SingleEmbedded{})
+	if assert.Len(nodes, 1) && assert.Len(nodes[0].Children, 1) {
+		assert.Equal("my_single_string", nodes[0].Name)
+		assert.Equal(KindMessage, nodes[0].Kind)
+		child := nodes[0].Children[0]
+		assert.Equal("the_string", child.Name)
+		assert.Equal("123", child.Value)
+		assert.Equal(1.0, child.Confidence)
+		assert.Equal("declared", child.Reason)
+	}
+
+	// a string that looks like a message is still a string
+	nodes = decode(&SingleString{TheString: "\x08\x01"}, &SingleString{})
+	if assert.Len(nodes, 1) {
+		assert.Equal(KindString, nodes[0].Kind)
+		assert.Equal("\x08\x01", nodes[0].Value)
+		assert.Nil(nodes[0].Children)
+		assert.Equal(KindMessage, nodes[0].Candidates[1].Kind)
+	}
+
+	// and an embedded message that wasn't guessed to be one is decoded
+	nodes, err := DecodeNodes([]byte{0x0a, 0x03, 0x0a, 0x01, 'a'}, Options{MinConfidence: 0.95, Type: descriptorOf(&SingleEmbedded{})})
+	assert.NoError(err)
+	if assert.Len(nodes, 1) && assert.Len(nodes[0].Children, 1) {
+		assert.Equal(KindMessage, nodes[0].Kind)
+		assert.Equal("a", nodes[0].Children[0].Value)
+		assert.Equal(2, nodes[0].Children[0].Offset)
+	}
+
+	nodes = decode(&SingleInt32{TheInt32: -5}, &SingleInt32{})
+	if assert.Len(nodes, 1) {
+		assert.Equal(KindSigned, nodes[0].Kind)
+		assert.Equal(int32(-5), nodes[0].Value)
+	}
+
+	nodes = decode(&RepeatedInt32{MyInt32S: []int32{1, -2, 3}}, &RepeatedInt32{})
+	if assert.Len(nodes, 1) {
+		assert.Equal(KindPacked, nodes[0].Kind)
+		assert.Equal(Packed{
+			WireType: WireVarint,
+			Values:   []uint64{1, 1<<64 - 2, 3},
+			Declared: []interface{}{int32(1), int32(-2), int32(3)},
+		}, nodes[0].Value)
+		assert.Equal("varint [1 -2 3]", FormatValue(nodes[0].Value))
+	}
+
+	// unknown fields and fields with the wrong wire type are guessed
+	nodes = decode(&SingleFixed32{TheFixed32: 7}, &SingleString{}, 0x10, 0x01)
+	if assert.Len(nodes, 2) {
+		assert.Empty(nodes[0].Name)
+		assert.Equal(uint32(7), nodes[0].Value)
+		assert.Empty(nodes[1].Name)
+		assert.Equal(uint64(1), nodes[1].Value)
+	}
+}
+
+func TestDecodeValue(t *testing.T) {
+	assert := assert.New(t)
+
+	inner, err := protov1.Marshal(&TwoStrings{String_1: "a", String_2: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := append(varints(1, 5), protowire.AppendBytes(protowire.AppendTag(nil, 2, protowire.BytesType), inner)...)
+	nodes, err := DecodeNodes(input, Options{MinConfidence: 0.95})
+	if !assert.NoError(err) || !assert.Len(nodes, 2) {
+		return
+	}
+	assert.NotEqual(KindMessage, nodes[1].Kind)
+
+	children, err := DecodeValue(input, nodes[1], Options{})
+	assert.NoError(err)
+	if assert.Len(children, 2) {
+		assert.Equal("a", children[0].Value)
+		assert.Equal(4, children[0].Offset)
+		assert.Equal(6, children[0].ValueOffset)
+		assert.Equal(input[children[1].ValueOffset:children[1].End], []byte("b"))
+	}
+
+	_, err = DecodeValue(input, nodes[0], Options{})
+	assert.Error(err)
+}
@@ -0,0 +1,288 @@
+package protoid
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// Shape summarises the structure of a set of messages: which fields appear, how often, and how they're encoded and interpreted.  Fields
+// are identified by their path of field numbers, as given by Path.String, with the elements of repeated fields sharing a path.  The zero
+// value is an empty Shape ready to use.  A Shape can be saved as JSON and loaded again.
+type Shape struct {
+	Samples int                    `json:"samples"`
+	Fields  map[string]*FieldStats `json:"fields"`
+}
+
+// FieldStats describes a single field of a Shape.
+type FieldStats struct {
+	// Seen is how many samples the field appeared in, and Repeated how many of those it appeared in more than once.
+	Seen     int `json:"seen"`
+	Repeated int `json:"repeated"`
+	// WireTypes and Kinds count how many times each wire type and interpretation was seen.
+	WireTypes map[WireType]int `json:"wire_types"`
+	Kinds     map[Kind]int     `json:"kinds"`
+	// Maps is how many of the samples the field appeared in had it as a map field: every occurrence a map entry, with the key at
+	// field 1 and the value at field 2, and at least two entries with consistently typed keys and values, as Decode detects maps.
+	// MapTypes counts the map types seen, such as "map<string,int64>".
+	Maps     int            `json:"maps,omitempty"`
+	MapTypes map[string]int `json:"map_types,omitempty"`
+}
+
+// MapType returns the inferred map type of the field, such as "map<string,int64>", if it was a map field in every sample it appeared
+// in.  Without a schema the types are only as good as protoid's guesses for the keys and values: keys are named after their wire type
+// where their readings disagree, and embedded message values are given as "message".
+func (fs *FieldStats) MapType() (string, bool) {
+	if fs.Seen == 0 || fs.Maps != fs.Seen {
+		return "", false
+	}
+	var best string
+	bestCount := -1
+	for t, c := range fs.MapTypes {
+		if c > bestCount || (c == bestCount && t < best) {
+			best, bestCount = t, c
+		}
+	}
+	return best, true
+}
+
+// Add decodes msg and adds its fields to s.
+func (s *Shape) Add(msg []byte) error {
+	nodes, err := DecodeNodes(msg, Options{})
+	if err != nil {
+		return err
+	}
+	s.AddNodes(nodes)
+	return nil
+}
+
+// AddNodes adds the fields of an already decoded message to s.
+func (s *Shape) AddNodes(nodes []*Node) {
+	if s.Fields == nil {
+		s.Fields = make(map[string]*FieldStats)
+	}
+	s.Samples++
+	counts := make(map[string]int)
+	maps := make(map[string]string)
+	s.addNodes(nodes, nil, counts, maps)
+	for path, n := range counts {
+		fs := s.Fields[path]
+		fs.Seen++
+		if n > 1 {
+			fs.Repeated++
+		}
+		if t := maps[path]; t != "" {
+			if fs.MapTypes == nil {
+				fs.MapTypes = make(map[string]int)
+			}
+			fs.Maps++
+			fs.MapTypes[t]++
+		}
+	}
+}
+
+// addNodes adds the fields of a message at path to s, counting the occurrences of each field in counts and recording in maps the map
+// type of each field that is a map, or "" for a field that isn't a map everywhere it appears in the sample.
+func (s *Shape) addNodes(nodes []*Node, path Path, counts map[string]int, maps map[string]string) {
+	byField := make(map[int][]*Node)
+	for _, n := range nodes {
+		byField[n.Field] = append(byField[n.Field], n)
+	}
+	for num, occurrences := range byField {
+		key := append(path[:len(path):len(path)], num).String()
+		t, ok := mapType(occurrences)
+		if prev, seen := maps[key]; !ok || seen && prev != t {
+			t = ""
+		}
+		maps[key] = t
+	}
+
+	for _, n := range nodes {
+		p := append(path, n.Field)
+		key := p.String()
+		fs := s.Fields[key]
+		if fs == nil {
+			fs = &FieldStats{WireTypes: make(map[WireType]int), Kinds: make(map[Kind]int)}
+			s.Fields[key] = fs
+		}
+		fs.WireTypes[n.WireType]++
+		fs.Kinds[n.Kind]++
+		counts[key]++
+		s.addNodes(n.Children, p, counts, maps)
+	}
+}
+
+// mapType returns the map type of a field whose occurrences in one message are given, if they look like the entries of a map.
+func mapType(occurrences []*Node) (string, bool) {
+	// a single entry can't be told from an ordinary embedded message
+	if len(occurrences) < 2 {
+		return "", false
+	}
+	var key, value string
+	keyWire, valueWire := WireType(-1), WireType(-1)
+	missingKeys := 0
+	for _, n := range occurrences {
+		if n.Kind != KindMessage || len(n.Children) == 0 || len(n.Children) > 2 {
+			return "", false
+		}
+		var k, v *Node
+		for _, c := range n.Children {
+			switch {
+			case c.Field == 1 && k == nil:
+				k = c
+			case c.Field == 2 && v == nil:
+				v = c
+			default:
+				return "", false
+			}
+		}
+		if k == nil {
+			// keys are unique, so only one entry can omit a default key
+			missingKeys++
+			if missingKeys > 1 {
+				return "", false
+			}
+		} else {
+			if keyWire >= 0 && k.WireType != keyWire {
+				return "", false
+			}
+			keyWire = k.WireType
+			key = commonMapFieldType(key, mapKeyTypeName(k), keyWire)
+		}
+		if v != nil {
+			if valueWire >= 0 && v.WireType != valueWire {
+				return "", false
+			}
+			valueWire = v.WireType
+			value = commonMapFieldType(value, mapValueTypeName(v), valueWire)
+		}
+	}
+	if key == "" || value == "" {
+		return "", false
+	}
+	return "map<" + key + "," + value + ">", true
+}
+
+// mapKeyTypeName returns the type of a map key.  Map keys can only be integers, bools or strings, so a length-delimited key is a string
+// whatever it looks like, and a fixed width key that looks like a float is an integer.
+func mapKeyTypeName(n *Node) string {
+	switch {
+	case n.WireType == WireBytes:
+		return "string"
+	case n.Kind == KindFloat && n.WireType == WireFixed32:
+		return "fixed32"
+	case n.Kind == KindFloat:
+		return "fixed64"
+	}
+	return mapValueTypeName(n)
+}
+
+// mapValueTypeName returns the type of a map value, as its best guess was read.
+func mapValueTypeName(n *Node) string {
+	switch n.Kind {
+	case KindString:
+		return "string"
+	case KindBytes, KindPacked:
+		return "bytes"
+	case KindMessage:
+		return "message"
+	case KindBool:
+		return "bool"
+	case KindZigzag:
+		return "sint64"
+	case KindFloat:
+		if n.WireType == WireFixed32 {
+			return "float"
+		}
+		return "double"
+	case KindSigned:
+		switch n.WireType {
+		case WireFixed32:
+			return "sfixed32"
+		case WireFixed64:
+			return "sfixed64"
+		}
+		return "int64"
+	default:
+		switch n.WireType {
+		case WireFixed32:
+			return "fixed32"
+		case WireFixed64:
+			return "fixed64"
+		}
+		return "uint64"
+	}
+}
+
+// commonMapFieldType returns a type that fits values of both types a and b, which have the given wire type.  a is "" for the first
+// value seen.  Where the guesses for different entries disagree, the signed integer type of the wire type, or bytes, fits them all.
+func commonMapFieldType(a, b string, wiretype WireType) string {
+	if a == "" || a == b {
+		return b
+	}
+	switch wiretype {
+	case WireVarint:
+		return "int64"
+	case WireFixed32:
+		return "sfixed32"
+	case WireFixed64:
+		return "sfixed64"
+	default:
+		return "bytes"
+	}
+}
+
+// dominant returns the key with the highest count, preferring the lowest key for a tie.
+func dominant[K ~int](counts map[K]int) K {
+	var best K
+	bestCount := -1
+	for k, c := range counts {
+		if c > bestCount || (c == bestCount && k < best) {
+			best, bestCount = k, c
+		}
+	}
+	return best
+}
+
+// Fingerprint identifies the structure of a message: which field paths it has, and their wire types.  Messages with the same fields
+// have the same fingerprint, whatever their values and however often repeated fields occur.
+type Fingerprint uint64
+
+func (f Fingerprint) String() string {
+	return fmt.Sprintf("%016x", uint64(f))
+}
+
+// ShapeFeatures returns the distinct fields of a decoded message as their path and wire type, e.g. "1.2:varint", sorted.
+func ShapeFeatures(nodes []*Node) []string {
+	seen := make(map[string]bool)
+	var features []string
+	var add func(nodes []*Node, path Path)
+	add = func(nodes []*Node, path Path) {
+		for _, n := range nodes {
+			p := append(path, n.Field)
+			f := p.String() + ":" + n.WireType.String()
+			if !seen[f] {
+				seen[f] = true
+				features = append(features, f)
+			}
+			add(n.Children, p)
+		}
+	}
+	add(nodes, nil)
+	sort.Strings(features)
+	return features
+}
+
+// ShapeFingerprint returns the fingerprint of a decoded message.
+func ShapeFingerprint(nodes []*Node) Fingerprint {
+	return featuresFingerprint(ShapeFeatures(nodes))
+}
+
+func featuresFingerprint(features []string) Fingerprint {
+	h := fnv.New64a()
+	for _, f := range features {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	return Fingerprint(h.Sum64())
+}
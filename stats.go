@@ -0,0 +1,201 @@
+package protoid
+
+import (
+	"math"
+	"math/bits"
+	"slices"
+)
+
+const (
+	// maxDistinct is how many distinct values of a field Stats keeps track of before giving up on counting them exactly.
+	maxDistinct = 10000
+	// maxExamples is how many example values of each field Stats keeps.
+	maxExamples = 3
+)
+
+// Stats gathers statistics about each field of a corpus of messages.  The zero value is ready to use.  Stats is not safe for
+// concurrent use.
+type Stats struct {
+	// Messages is how many messages have been added.
+	Messages int
+
+	fields map[string]*fieldStats
+	counts map[string]int // occurrences of each field in the current message
+	dec    *Decoder
+	res    Result
+}
+
+// FieldReport holds the statistics for a single field, identified by its path of field numbers as given by Path.String.  The elements
+// of repeated fields share a path.
+type FieldReport struct {
+	Path string `json:"path"`
+	// Count is how many times the field occurred, Messages how many messages it occurred in, and Presence the fraction of all messages
+	// that is.
+	Count    int     `json:"count"`
+	Messages int     `json:"messages"`
+	Presence float64 `json:"presence"`
+	// WireTypes and Kinds count how many times each wire type and interpretation was seen.
+	WireTypes map[WireType]int `json:"wire_types"`
+	Kinds     map[Kind]int     `json:"kinds"`
+	// Cardinality counts the messages the field occurred in by how many times it occurred in them, so a field that is never repeated
+	// only has a count for 1.
+	Cardinality map[int]int `json:"cardinality"`
+	// Numeric is the range of the numeric values of the field, if it had any.  Integers beyond 2^53 are approximate.
+	Numeric *NumericRange `json:"numeric,omitempty"`
+	// Distinct is how many different values the field had.  If there were too many to count it stops, and DistinctCapped is set.
+	Distinct       int  `json:"distinct"`
+	DistinctCapped bool `json:"distinct_capped,omitempty"`
+	// StringLengths is the distribution of the lengths in bytes of the string values of the field.
+	StringLengths []LengthBucket `json:"string_lengths,omitempty"`
+	// Examples holds the first few distinct values of the field, as formatted by FormatValue.
+	Examples []string `json:"examples"`
+}
+
+// NumericRange is the smallest and largest numeric value of a field.
+type NumericRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// LengthBucket counts the values with a length between Min and Max inclusive.
+type LengthBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+type fieldStats struct {
+	report   FieldReport
+	distinct map[interface{}]struct{}
+	lengths  [65]int // string lengths, bucketed by their bit length
+}
+
+// Add decodes msg and adds its fields to the statistics.
+func (s *Stats) Add(msg []byte) error {
+	if s.dec == nil {
+		s.dec = NewDecoder(Options{})
+	}
+	if err := s.dec.DecodeInto(msg, &s.res); err != nil {
+		return err
+	}
+	s.AddNodes(s.res.Fields)
+	return nil
+}
+
+// AddNodes adds the fields of an already decoded message to the statistics.
+func (s *Stats) AddNodes(nodes []*Node) {
+	if s.fields == nil {
+		s.fields = make(map[string]*fieldStats)
+		s.counts = make(map[string]int)
+	}
+	s.Messages++
+	clear(s.counts)
+	s.addNodes(nodes, nil)
+	for path, n := range s.counts {
+		r := &s.fields[path].report
+		r.Messages++
+		r.Cardinality[n]++
+	}
+}
+
+func (s *Stats) addNodes(nodes []*Node, path Path) {
+	for _, n := range nodes {
+		p := append(path, n.Field)
+		key := p.String()
+		fs := s.fields[key]
+		if fs == nil {
+			fs = &fieldStats{
+				report: FieldReport{
+					Path:        key,
+					WireTypes:   make(map[WireType]int),
+					Kinds:       make(map[Kind]int),
+					Cardinality: make(map[int]int),
+				},
+				distinct: make(map[interface{}]struct{}),
+			}
+			s.fields[key] = fs
+		}
+		s.counts[key]++
+		fs.add(n)
+		s.addNodes(n.Children, p)
+	}
+}
+
+func (fs *fieldStats) add(n *Node) {
+	r := &fs.report
+	r.Count++
+	r.WireTypes[n.WireType]++
+	r.Kinds[n.Kind]++
+	if n.Kind == KindMessage {
+		return
+	}
+
+	if f, ok := numericValue(n.Value); ok {
+		if r.Numeric == nil {
+			r.Numeric = &NumericRange{Min: f, Max: f}
+		}
+		r.Numeric.Min = math.Min(r.Numeric.Min, f)
+		r.Numeric.Max = math.Max(r.Numeric.Max, f)
+	}
+	if str, ok := n.Value.(string); ok {
+		fs.lengths[bits.Len(uint(len(str)))]++
+	}
+
+	key := n.Value
+	if b, ok := key.([]byte); ok {
+		key = string(b)
+	}
+	if _, seen := fs.distinct[key]; !seen && !r.DistinctCapped {
+		if len(fs.distinct) == maxDistinct {
+			r.DistinctCapped = true
+		} else {
+			fs.distinct[key] = struct{}{}
+			if len(r.Examples) < maxExamples {
+				r.Examples = append(r.Examples, FormatValue(n.Value))
+			}
+		}
+	}
+}
+
+// numericValue converts a decoded integer or floating point value to a float64.
+func numericValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Report returns the statistics for every field seen so far, ordered by path.
+func (s *Stats) Report() []FieldReport {
+	reports := make([]FieldReport, 0, len(s.fields))
+	for _, fs := range s.fields {
+		r := fs.report
+		r.Presence = float64(r.Messages) / float64(s.Messages)
+		r.Distinct = len(fs.distinct)
+		r.StringLengths = nil
+		for i, count := range fs.lengths {
+			if count == 0 {
+				continue
+			}
+			b := LengthBucket{Count: count}
+			if i > 0 {
+				b.Min, b.Max = 1<<(i-1), 1<<i-1
+			}
+			r.StringLengths = append(r.StringLengths, b)
+		}
+		reports = append(reports, r)
+	}
+	slices.SortFunc(reports, func(a, b FieldReport) int { return comparePaths(a.Path, b.Path) })
+	return reports
+}
@@ -0,0 +1,50 @@
+package protoid
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	assert := assert.New(t)
+
+	var s Stats
+	for _, m := range []proto.Message{
+		&TwoStrings{String_1: "a", String_2: "hello"},
+		&TwoStrings{String_1: "a"},
+		&TwoStrings{String_1: "abc"},
+	} {
+		ser, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NoError(s.Add(ser))
+	}
+	assert.NoError(s.Add(varints(1, 1, 2, 300)))
+	assert.Error(s.Add([]byte{0x0a}))
+	assert.Equal(4, s.Messages)
+
+	report := s.Report()
+	if !assert.Len(report, 2) {
+		return
+	}
+	assert.Equal(FieldReport{
+		Path:          "1",
+		Count:         6,
+		Messages:      4,
+		Presence:      1,
+		WireTypes:     map[WireType]int{WireBytes: 3, WireVarint: 3},
+		Kinds:         map[Kind]int{KindString: 3, KindUnsigned: 3},
+		Cardinality:   map[int]int{1: 3, 3: 1},
+		Numeric:       &NumericRange{Min: 1, Max: 300},
+		Distinct:      5,
+		StringLengths: []LengthBucket{{Min: 1, Max: 1, Count: 2}, {Min: 2, Max: 3, Count: 1}},
+		Examples:      []string{`"a"`, `"abc"`, "1"},
+	}, report[0])
+	assert.Equal("2", report[1].Path)
+	assert.Equal(0.25, report[1].Presence)
+	assert.Nil(report[1].Numeric)
+	assert.Equal([]LengthBucket{{Min: 4, Max: 7, Count: 1}}, report[1].StringLengths)
+}
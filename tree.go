@@ -0,0 +1,31 @@
+package protoid
+
+// Tree is a decoded message along with the input it was decoded from, as used by the formatters.  A Tree can describe a malformed
+// message: the fields that could be decoded are kept, along with where and why decoding stopped.
+type Tree struct {
+	Input  []byte
+	Fields []*Node
+	// Parsed is the length of the prefix of Input that the fields were decoded from.  Anything after it couldn't be parsed.
+	Parsed int
+	// Err is the reason that the rest of Input couldn't be parsed, if any.
+	Err error
+}
+
+// DecodeTree decodes as much of input as it can.  Unlike DecodeNodes it doesn't give up on a malformed message, so it is useful for
+// debugging.
+func DecodeTree(input []byte, opts Options) *Tree {
+	t := &Tree{Input: input, Parsed: len(input)}
+
+	spans, err := scanFields(input, nil)
+	if err != nil {
+		t.Err = err
+		t.Parsed = 0
+		if len(spans) > 0 {
+			t.Parsed = spans[len(spans)-1].end
+		}
+	}
+
+	// Every field up to Parsed is complete, so this can't fail.
+	t.Fields, _ = DecodeNodes(input[:t.Parsed], opts)
+	return t
+}
@@ -0,0 +1,199 @@
+package protoid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SkipMessage can be returned by Visitor.EnterMessage to have Walk pass the message to Visitor.Bytes instead of descending into it.
+var SkipMessage = errors.New("skip this message")
+
+// Path identifies a field within a message by the field numbers leading to it from the outermost message.
+type Path []int
+
+func (p Path) String() string {
+	parts := make([]string, len(p))
+	for i, n := range p {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ".")
+}
+
+// ParsePath parses a path in the form returned by Path.String, such as "1.3.2".
+func ParsePath(s string) (Path, error) {
+	var p Path
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 1 || n > maxFieldNumber {
+			return nil, fmt.Errorf("invalid path %q: %q is not a field number", s, part)
+		}
+		p = append(p, n)
+	}
+	return p, nil
+}
+
+// Visitor receives the fields of a message from Walk.  Each callback is given the path of the field, ending with its own field number.
+// The path is only valid for the duration of the call and must be copied if it is retained.  Returning an error stops the walk, and the
+// error is returned by Walk.
+type Visitor interface {
+	// Varint is called for a varint value (int32, int64, uint32, uint64, sint32, sint64, bool, enum).
+	Varint(path Path, value uint64) error
+	// Fixed64 is called for a 64 bit value (fixed64, sfixed64, double).
+	Fixed64(path Path, value uint64) error
+	// Bytes is called for a length-delimited value that doesn't appear to be an embedded message (string, bytes, packed repeated fields).
+	Bytes(path Path, value []byte) error
+	// Fixed32 is called for a 32 bit value (fixed32, sfixed32, float).
+	Fixed32(path Path, value uint32) error
+	// EnterMessage is called before the fields of an embedded message, with its encoded value.  It can return SkipMessage to have the
+	// message treated as a plain length-delimited value instead.
+	EnterMessage(path Path, value []byte) error
+	// LeaveMessage is called after the fields of an embedded message.
+	LeaveMessage(path Path) error
+}
+
+// Walk parses input as a protocol buffers message, calling the methods of v for each field in the order they appear.  Length-delimited
+// values that look like embedded messages are walked recursively.  The structure of each message is checked before any of its fields are
+// passed to v, so nothing is reported for a message that turns out to be malformed.
+func Walk(input []byte, v Visitor) error {
+	var w walker
+	return w.walk(context.Background(), input, v)
+}
+
+// classifiedVisitor is implemented by visitors in this package that need the candidates Walk has already worked out for each
+// length-delimited value, rather than classifying it again themselves.  The candidates are only valid for the duration of the call.
+type classifiedVisitor interface {
+	Visitor
+	classifiedBytes(path Path, value []byte, cands []Candidate) error
+	classifiedEnterMessage(path Path, value []byte, cands []Candidate) error
+}
+
+// walker walks a message, parsing each level of it exactly once.  spans and path are used as stacks: the fields of each embedded message
+// are appended while it is walked, and removed afterwards.
+type walker struct {
+	v     Visitor
+	cv    classifiedVisitor
+	spans []fieldSpan
+	path  Path
+	cands [3]Candidate
+
+	// fields counts the fields walked, so that the context can be checked
+	// every cancelCheckInterval of them.
+	fields int
+
+	// field is the field currently being visited, with its offsets
+	// relative to the start of the input.
+	field fieldSpan
+}
+
+const cancelCheckInterval = 256
+
+// walk walks input with v, reusing any buffers w already has.  ctx is checked every cancelCheckInterval fields, and while scanning and
+// classifying values, so that walking a huge message can be abandoned part way through.
+func (w *walker) walk(ctx context.Context, input []byte, v Visitor) error {
+	w.v = v
+	w.cv, _ = v.(classifiedVisitor)
+	defer func() {
+		w.v, w.cv = nil, nil
+	}()
+
+	var err error
+	w.spans, err = scanFieldsContext(ctx, input, w.spans[:0])
+	if err != nil {
+		return err
+	}
+	return w.walkSpans(ctx, 0, 0, 0)
+}
+
+// reset drops the references w holds to the last input, so that it can be kept for reuse.
+func (w *walker) reset() {
+	clear(w.spans[:cap(w.spans)])
+	w.spans = w.spans[:0]
+	w.path = w.path[:0]
+	w.fields = 0
+}
+
+// walkSpans walks the fields in spans from start to the end, which must all belong to the message at the given depth starting at offset
+// base in the input.
+func (w *walker) walkSpans(ctx context.Context, start, depth, base int) error {
+	end := len(w.spans)
+	for i := start; i < end; i++ {
+		// copy, as walking an embedded message may reallocate spans
+		s := w.spans[i]
+		w.fields++
+		if w.fields%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		s.off += base
+		s.valueOff += base
+		s.end += base
+		w.field = s
+		w.path = append(w.path[:depth], s.num)
+		p := w.path
+		var err error
+		switch s.wiretype {
+		case WireVarint:
+			err = w.v.Varint(p, s.value)
+		case WireFixed64:
+			err = w.v.Fixed64(p, s.value)
+		case WireBytes:
+			err = w.walkBytes(ctx, p, s)
+		case WireFixed32:
+			err = w.v.Fixed32(p, uint32(s.value))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkBytes passes a length-delimited value to the visitor, descending into it if it looks like an embedded message.
+func (w *walker) walkBytes(ctx context.Context, path Path, field fieldSpan) error {
+	data := field.data
+	start := len(w.spans)
+	defer func() { w.spans = w.spans[:start] }()
+
+	var err error
+	w.spans, err = scanFieldsContext(ctx, data, w.spans)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	cands, err := bytesCandidatesContext(ctx, data, w.spans[start:], err == nil, w.cands[:])
+	if err != nil {
+		return err
+	}
+
+	if cands[0].Kind != KindMessage {
+		return w.bytes(path, data, cands)
+	}
+
+	if w.cv != nil {
+		err = w.cv.classifiedEnterMessage(path, data, cands)
+	} else {
+		err = w.v.EnterMessage(path, data)
+	}
+	switch err {
+	case nil:
+	case SkipMessage:
+		return w.bytes(path, data, cands)
+	default:
+		return err
+	}
+	if err := w.walkSpans(ctx, start, len(path), field.valueOff); err != nil {
+		return err
+	}
+	w.field = field
+	return w.v.LeaveMessage(path)
+}
+
+func (w *walker) bytes(path Path, data []byte, cands []Candidate) error {
+	if w.cv != nil {
+		return w.cv.classifiedBytes(path, data, cands)
+	}
+	return w.v.Bytes(path, data)
+}
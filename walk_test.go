@@ -0,0 +1,115 @@
+package protoid
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingVisitor records every event it receives as a string.
+type recordingVisitor struct {
+	events []string
+	skip   bool
+}
+
+func (rv *recordingVisitor) Varint(path Path, value uint64) error {
+	rv.events = append(rv.events, fmt.Sprintf("varint %v %d", path, value))
+	return nil
+}
+
+func (rv *recordingVisitor) Fixed64(path Path, value uint64) error {
+	rv.events = append(rv.events, fmt.Sprintf("fixed64 %v %d", path, value))
+	return nil
+}
+
+func (rv *recordingVisitor) Bytes(path Path, value []byte) error {
+	rv.events = append(rv.events, fmt.Sprintf("bytes %v %q", path, value))
+	return nil
+}
+
+func (rv *recordingVisitor) Fixed32(path Path, value uint32) error {
+	rv.events = append(rv.events, fmt.Sprintf("fixed32 %v %d", path, value))
+	return nil
+}
+
+func (rv *recordingVisitor) EnterMessage(path Path, value []byte) error {
+	if rv.skip {
+		return SkipMessage
+	}
+	rv.events = append(rv.events, fmt.Sprintf("enter %v", path))
+	return nil
+}
+
+func (rv *recordingVisitor) LeaveMessage(path Path) error {
+	rv.events = append(rv.events, fmt.Sprintf("leave %v", path))
+	return nil
+}
+
+func TestWalk(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &RepeatedEmbedded{MySingleStrings: []*SingleString{
+		{TheString: "123"}, {TheString: "456"},
+	}}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv := &recordingVisitor{}
+	if err := Walk(ser, rv); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal([]string{
+		"enter 1",
+		`bytes 1.1 "123"`,
+		"leave 1",
+		"enter 1",
+		`bytes 1.1 "456"`,
+		"leave 1",
+	}, rv.events)
+}
+
+func TestWalkSkipMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	ss := &SingleEmbedded{MySingleString: &SingleString{TheString: "123"}}
+	ser, err := proto.Marshal(ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rv := &recordingVisitor{skip: true}
+	if err := Walk(ser, rv); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal([]string{`bytes 1 "\n\x03123"`}, rv.events)
+}
+
+func TestWalkTruncated(t *testing.T) {
+	assert := assert.New(t)
+
+	rv := &recordingVisitor{}
+	err := Walk([]byte{0x08, 0x96}, rv)
+
+	assert.Equal(ErrUnexpectedEndOfInput, err)
+	assert.Empty(rv.events)
+}
+
+func TestParsePath(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := ParsePath("1.30.2")
+	assert.NoError(err)
+	assert.Equal(Path{1, 30, 2}, p)
+	assert.Equal("1.30.2", p.String())
+
+	for _, s := range []string{"", "1..2", "0", "1.x", "-1"} {
+		_, err := ParsePath(s)
+		assert.Error(err, s)
+	}
+}
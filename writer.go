@@ -0,0 +1,28 @@
+package protoid
+
+import "encoding/binary"
+
+func appendVarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func appendTag(buf []byte, num int, wiretype WireType) []byte {
+	return appendVarint(buf, uint64(num)<<3|uint64(wiretype))
+}
+
+func appendLeUint32(buf []byte, v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(buf, v)
+}
+
+func appendLeUint64(buf []byte, v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(buf, v)
+}
+
+func appendLenDelimValue(buf []byte, v []byte) []byte {
+	buf = appendVarint(buf, uint64(len(v)))
+	return append(buf, v...)
+}